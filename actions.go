@@ -1,3 +1,6 @@
 package goauthlib
 
-const deleteAccountAction = "delete-account"
+const DeleteAccountAction = "delete-account"
+
+// MagicLinkAction keeps the nonce of the last magic link of an entity apart from the code sent to it.
+const MagicLinkAction = "magic-link"
@@ -0,0 +1,149 @@
+package goauthlib
+
+import (
+	"context"
+	"fmt"
+	"github.com/techpro-studio/gohttplib"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Authentication methods recorded in amr claim.
+const (
+	AuthMethodEmailCode = "email_otp"
+	AuthMethodPhoneCode = "sms_otp"
+	AuthMethodMagicLink = "magic_link"
+	// AuthMethodTOTP is "otp" of RFC 8176. Applications verifying TOTP themselves record it in TokenOptions.AuthMethods.
+	AuthMethodTOTP = "otp"
+)
+
+// Authentication levels recorded in acr claim.
+const (
+	AuthLevelSingleFactor = 1
+	AuthLevelMultiFactor  = 2
+)
+
+const StepUpTokenContextKey = "step_up_token_key"
+
+const socialAuthMethodPrefix = "social:"
+
+// SocialAuthMethod is amr value of login via social provider, e.g. "social:google".
+func SocialAuthMethod(provider string) string {
+	return socialAuthMethodPrefix + provider
+}
+
+func authMethodForEntity(entity AuthorizationEntity) string {
+	switch entity.Type {
+	case EntityTypeEmail:
+		return AuthMethodEmailCode
+	case EntityTypePhone:
+		return AuthMethodPhoneCode
+	default:
+		return entity.Type + "_otp"
+	}
+}
+
+// authLevel counts distinct methods, so a login with two different factors is multi factor.
+func authLevel(methods []string) int {
+	distinct := map[string]bool{}
+	for _, method := range methods {
+		distinct[method] = true
+	}
+	switch len(distinct) {
+	case 0:
+		return 0
+	case 1:
+		return AuthLevelSingleFactor
+	default:
+		return AuthLevelMultiFactor
+	}
+}
+
+// WithStepUpToken passes the token the client already has to a login. When the login is of the same user,
+// its method is added to methods of the token and the session is kept, so the user reaches multi factor acr
+// by logging in with a second method.
+func WithStepUpToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, StepUpTokenContextKey, token)
+}
+
+func StepUpTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(StepUpTokenContextKey).(string)
+	return token
+}
+
+// stepUpMethods adds the method to methods of the previous login, keeping their order.
+func stepUpMethods(previous []string, method string) []string {
+	if slices.Contains(previous, method) {
+		return slices.Clone(previous)
+	}
+	return append(slices.Clone(previous), method)
+}
+
+// StepUpPolicy is the authentication required by sensitive routes.
+type StepUpPolicy struct {
+	// MaxAge is the longest time since the user authenticated. Zero disables the check.
+	MaxAge time.Duration
+	// Methods are accepted authentication methods, at least one of them must be in amr. Empty accepts any method.
+	Methods []string
+	// MinLevel is the lowest accepted acr. Zero disables the check. AuthLevelMultiFactor is reached by a second login
+	// with another method while the client has a valid token, see WithStepUpToken.
+	MinLevel int
+}
+
+var stepUpRequiredAuthTime = gohttplib.NewServerError(401, "STEP_UP_REQUIRED", "Recent authentication is required", "auth_time", nil)
+var stepUpRequiredMethod = gohttplib.NewServerError(401, "STEP_UP_REQUIRED", "Stronger authentication is required", "amr", nil)
+
+// check returns the first requirement the token doesn't satisfy.
+func (policy StepUpPolicy) check(info *TokenInfo) error {
+	if policy.MaxAge > 0 && (info.AuthTime.IsZero() || time.Since(info.AuthTime) > policy.MaxAge) {
+		return stepUpRequiredAuthTime
+	}
+	if len(policy.Methods) > 0 && !slices.ContainsFunc(info.AuthMethods, func(method string) bool {
+		return slices.Contains(policy.Methods, method)
+	}) {
+		return stepUpRequiredMethod
+	}
+	if policy.MinLevel > 0 && info.AuthLevel < policy.MinLevel {
+		return stepUpRequiredMethod
+	}
+	return nil
+}
+
+// challenge is WWW-Authenticate header of RFC 9470, so clients know what login to ask for.
+func (policy StepUpPolicy) challenge(err error) string {
+	params := []string{`error="insufficient_user_authentication"`}
+	if serverError, ok := err.(gohttplib.ServerError); ok {
+		params = append(params, fmt.Sprintf("error_description=%q", serverError.Description))
+	}
+	if policy.MaxAge > 0 {
+		params = append(params, "max_age="+strconv.Itoa(int(policy.MaxAge.Seconds())))
+	}
+	if policy.MinLevel > 0 {
+		params = append(params, fmt.Sprintf("acr_values=%q", strconv.Itoa(policy.MinLevel)))
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// StepUpMiddlewareFactory rejects tokens whose authentication is too old or too weak for the route.
+// It must be used after UserMiddlewareFactory.
+func StepUpMiddlewareFactory(policy StepUpPolicy) gohttplib.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			info := GetTokenInfoFromRequest(req)
+			if info == nil {
+				gohttplib.HTTP401().Write(w)
+				return
+			}
+			err := policy.check(info)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", policy.challenge(err))
+				gohttplib.SafeConvertToServerError(err).Write(w)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package goauthlib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestAuthContextClaims(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	token, err := jwtCfg.GenerateToken(context.Background(), User{ID: bson.NewObjectID().Hex()}, TokenOptions{
+		AuthMethods: []string{AuthMethodEmailCode, AuthMethodPhoneCode},
+		AuthTime:    authTime,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	info, err := jwtCfg.ParseToken(token)
+	if err != nil {
+		t.Fatalf("ParseToken failed: %v", err)
+	}
+	if len(info.AuthMethods) != 2 || info.AuthMethods[0] != AuthMethodEmailCode {
+		t.Errorf("unexpected amr: %v", info.AuthMethods)
+	}
+	if info.AuthLevel != AuthLevelMultiFactor {
+		t.Errorf("expected multi factor acr, got %d", info.AuthLevel)
+	}
+	if !info.AuthTime.Equal(authTime) {
+		t.Errorf("expected auth_time %v, got %v", authTime, info.AuthTime)
+	}
+}
+
+func TestTOTPStepUpPolicy(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	policy := StepUpPolicy{Methods: []string{AuthMethodTOTP}, MinLevel: AuthLevelMultiFactor}
+	user := User{ID: bson.NewObjectID().Hex()}
+
+	for methods, allowed := range map[string]bool{AuthMethodEmailCode: false, AuthMethodEmailCode + " " + AuthMethodTOTP: true} {
+		token, err := jwtCfg.GenerateToken(context.Background(), user, TokenOptions{AuthMethods: strings.Fields(methods), AuthTime: time.Now()})
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		info, err := jwtCfg.ParseToken(token)
+		if err != nil {
+			t.Fatalf("ParseToken failed: %v", err)
+		}
+		if err := policy.check(info); (err == nil) != allowed {
+			t.Errorf("amr %v: expected allowed %v, got %v", info.AuthMethods, allowed, err)
+		}
+	}
+}
+
+func TestStepUpMiddleware(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	user := User{ID: bson.NewObjectID().Hex()}
+	handler := UserMiddlewareFactory(*jwtCfg)(StepUpMiddlewareFactory(StepUpPolicy{
+		MaxAge:  5 * time.Minute,
+		Methods: []string{AuthMethodEmailCode, AuthMethodPhoneCode},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	cases := []struct {
+		name    string
+		options TokenOptions
+		status  int
+	}{
+		{"recent code login", TokenOptions{AuthMethods: []string{AuthMethodEmailCode}, AuthTime: time.Now()}, http.StatusOK},
+		{"old login", TokenOptions{AuthMethods: []string{AuthMethodEmailCode}, AuthTime: time.Now().Add(-time.Hour)}, http.StatusUnauthorized},
+		{"weak method", TokenOptions{AuthMethods: []string{SocialAuthMethod("google")}, AuthTime: time.Now()}, http.StatusUnauthorized},
+		{"no auth context", TokenOptions{}, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := jwtCfg.GenerateToken(context.Background(), user, tc.options)
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/force-delete", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, rec.Code)
+			}
+			if tc.status == http.StatusUnauthorized {
+				challenge := rec.Header().Get("WWW-Authenticate")
+				if !strings.Contains(challenge, "insufficient_user_authentication") || !strings.Contains(challenge, "max_age=300") {
+					t.Errorf("unexpected challenge: %s", challenge)
+				}
+				if !strings.Contains(rec.Body.String(), "STEP_UP_REQUIRED") {
+					t.Errorf("expected STEP_UP_REQUIRED error, got %s", rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestStepUpLogin(t *testing.T) {
+	ctx := context.Background()
+	user := &User{ID: bson.NewObjectID().Hex()}
+	sessions := &testSessionRepository{sessions: map[string]*Session{}}
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetSessionRepository(sessions)
+	useCase := NewDefaultUseCase(nil, *jwtCfg, DoNothingCallback())
+	multiFactor := StepUpPolicy{MinLevel: AuthLevelMultiFactor}
+
+	first, err := useCase.generateResponseFor(ctx, user, nil, AuthMethodEmailCode)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	firstInfo, _ := jwtCfg.ValidateToken(ctx, first.Token)
+	if firstInfo.AuthLevel != AuthLevelSingleFactor || multiFactor.check(firstInfo) == nil {
+		t.Fatalf("expected single factor login, got acr %d", firstInfo.AuthLevel)
+	}
+
+	second, err := useCase.generateResponseFor(WithStepUpToken(ctx, first.Token), user, nil, AuthMethodPhoneCode)
+	if err != nil {
+		t.Fatalf("step-up login failed: %v", err)
+	}
+	info, err := jwtCfg.ValidateToken(ctx, second.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if info.AuthLevel != AuthLevelMultiFactor || len(info.AuthMethods) != 2 || info.AuthMethods[1] != AuthMethodPhoneCode {
+		t.Errorf("expected both methods and multi factor acr, got %v %d", info.AuthMethods, info.AuthLevel)
+	}
+	if info.SessionID != firstInfo.SessionID || len(sessions.sessions) != 1 {
+		t.Errorf("expected the session to be kept, got %q of %d sessions", info.SessionID, len(sessions.sessions))
+	}
+	if err := multiFactor.check(info); err != nil {
+		t.Errorf("expected multi factor policy to pass, got %v", err)
+	}
+
+	again, err := useCase.generateResponseFor(WithStepUpToken(ctx, first.Token), user, nil, AuthMethodEmailCode)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if info, _ := jwtCfg.ValidateToken(ctx, again.Token); info.AuthLevel != AuthLevelSingleFactor {
+		t.Errorf("expected the same method not to count twice, got acr %d", info.AuthLevel)
+	}
+
+	other, err := jwtCfg.GenerateToken(ctx, User{ID: bson.NewObjectID().Hex()}, TokenOptions{SessionID: sessions.CreateSession(ctx, Session{}).ID, AuthMethods: []string{AuthMethodEmailCode}})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	impersonation, err := jwtCfg.GenerateToken(ctx, *user, TokenOptions{SessionID: firstInfo.SessionID, AuthMethods: []string{AuthMethodEmailCode}, Actor: &Actor{ID: "admin"}})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	for name, token := range map[string]string{"another user": other, "impersonation": impersonation, "invalid": "broken"} {
+		response, err := useCase.generateResponseFor(WithStepUpToken(ctx, token), user, nil, AuthMethodPhoneCode)
+		if err != nil {
+			t.Fatalf("%s: login failed: %v", name, err)
+		}
+		if info, _ := jwtCfg.ValidateToken(ctx, response.Token); info.AuthLevel != AuthLevelSingleFactor || info.SessionID == firstInfo.SessionID {
+			t.Errorf("%s: expected a new single factor login, got acr %d", name, info.AuthLevel)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package goauthlib
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+const (
+	NumericAlphabet = "0123456789"
+	// AlphanumericAlphabet has no characters which are easy to confuse, like 0 and O or 1 and I.
+	AlphanumericAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+)
+
+// CodeSpec describes codes sent to one type of entities. Codes are checked against it before verification.
+type CodeSpec struct {
+	Length   int
+	Alphabet string
+}
+
+func DefaultCodeSpec() CodeSpec {
+	return CodeSpec{Length: 6, Alphabet: NumericAlphabet}
+}
+
+// Normalize makes codes typed in lower case match upper case alphabets.
+func (spec CodeSpec) Normalize(code string) string {
+	code = strings.TrimSpace(code)
+	if strings.ToUpper(spec.Alphabet) == spec.Alphabet {
+		return strings.ToUpper(code)
+	}
+	return code
+}
+
+// Matches reports whether the code could be generated by the spec.
+func (spec CodeSpec) Matches(code string) bool {
+	if len(code) != spec.Length {
+		return false
+	}
+	for _, char := range code {
+		if !strings.ContainsRune(spec.Alphabet, char) {
+			return false
+		}
+	}
+	return true
+}
+
+// CodeGenerator makes codes for SendCode and SendVerificationCode.
+type CodeGenerator interface {
+	Generate(entityType string) (string, error)
+	Spec(entityType string) CodeSpec
+}
+
+// RandomCodeGenerator generates codes with crypto/rand. Entity types without own spec use the default one.
+type RandomCodeGenerator struct {
+	defaultSpec CodeSpec
+	specs       map[string]CodeSpec
+}
+
+func NewRandomCodeGenerator(defaultSpec CodeSpec) *RandomCodeGenerator {
+	return &RandomCodeGenerator{defaultSpec: defaultSpec, specs: map[string]CodeSpec{}}
+}
+
+// SetSpec changes codes for the entity type, e.g. longer alphanumeric codes for EntityTypeEmail.
+func (g *RandomCodeGenerator) SetSpec(entityType string, spec CodeSpec) {
+	g.specs[entityType] = spec
+}
+
+func (g *RandomCodeGenerator) Spec(entityType string) CodeSpec {
+	spec, ok := g.specs[entityType]
+	if !ok {
+		return g.defaultSpec
+	}
+	return spec
+}
+
+func (g *RandomCodeGenerator) Generate(entityType string) (string, error) {
+	spec := g.Spec(entityType)
+	if spec.Length <= 0 || len(spec.Alphabet) < 2 {
+		return "", errors.New("invalid code spec")
+	}
+	alphabetSize := big.NewInt(int64(len(spec.Alphabet)))
+	code := make([]byte, spec.Length)
+	for i := range code {
+		idx, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		code[i] = spec.Alphabet[idx.Int64()]
+	}
+	return string(code), nil
+}
+
+// DeterministicCodeGenerator returns given codes in order and then repeats the last one. It is meant for tests.
+type DeterministicCodeGenerator struct {
+	mu    sync.Mutex
+	spec  CodeSpec
+	codes []string
+	next  int
+}
+
+func NewDeterministicCodeGenerator(spec CodeSpec, codes ...string) *DeterministicCodeGenerator {
+	return &DeterministicCodeGenerator{spec: spec, codes: codes}
+}
+
+func (g *DeterministicCodeGenerator) Spec(entityType string) CodeSpec {
+	return g.spec
+}
+
+func (g *DeterministicCodeGenerator) Generate(entityType string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.codes) == 0 {
+		return "", errors.New("no codes to generate")
+	}
+	code := g.codes[min(g.next, len(g.codes)-1)]
+	g.next++
+	return code, nil
+}
@@ -0,0 +1,71 @@
+package goauthlib
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRandomCodeGenerator(t *testing.T) {
+	generator := NewRandomCodeGenerator(DefaultCodeSpec())
+	generator.SetSpec(EntityTypeEmail, CodeSpec{Length: 8, Alphabet: AlphanumericAlphabet})
+
+	for _, entityType := range []string{EntityTypePhone, EntityTypeEmail} {
+		spec := generator.Spec(entityType)
+		for i := 0; i < 100; i++ {
+			code, err := generator.Generate(entityType)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !spec.Matches(code) {
+				t.Fatalf("code %q doesn't match spec %+v", code, spec)
+			}
+		}
+	}
+
+	if _, err := NewRandomCodeGenerator(CodeSpec{}).Generate(EntityTypeEmail); err == nil {
+		t.Error("expected error for empty spec")
+	}
+}
+
+func TestGetCodeForSpec(t *testing.T) {
+	spec := CodeSpec{Length: 6, Alphabet: AlphanumericAlphabet}
+	code, err := GetCodeForSpec(map[string]interface{}{"code": " ab23cd "}, spec)
+	if err != nil || code != "AB23CD" {
+		t.Fatalf("expected normalized code, got %q, %v", code, err)
+	}
+	for _, invalid := range []string{"AB23C", "AB23CDE", "AB23C0"} {
+		if _, err := GetCodeForSpec(map[string]interface{}{"code": invalid}, spec); err == nil || err.Error() != invalidCodeFormat.Error() {
+			t.Errorf("expected INVALID_CODE_FORMAT for %q, got %v", invalid, err)
+		}
+	}
+}
+
+func TestSendCodeUsesCodeGenerator(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	delivery := &testOTPDelivery{}
+	repository := &testSendRepository{}
+	useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+	useCase.RegisterOTPDelivery(EntityTypeEmail, delivery)
+	useCase.SetCodeGenerator(NewDeterministicCodeGenerator(CodeSpec{Length: 4, Alphabet: NumericAlphabet}, "1111", "2222"))
+
+	entity := AuthorizationEntity{Type: EntityTypeEmail, Value: "generator@test.com"}
+	for i := 0; i < 3; i++ {
+		if err := useCase.SendCode(context.Background(), entity); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expected := []string{"1111", "2222", "2222"}
+	for i, code := range expected {
+		if delivery.sent[i] != code {
+			t.Errorf("expected code %s, got %s", code, delivery.sent[i])
+		}
+	}
+	if repository.codeHash != useCase.hashCode("2222") {
+		t.Errorf("expected HMAC of the code to be stored, got %q", repository.codeHash)
+	}
+	if useCase.CodeSpec(EntityTypeEmail).Length != 4 {
+		t.Errorf("expected spec of the generator, got %+v", useCase.CodeSpec(EntityTypeEmail))
+	}
+}
@@ -0,0 +1,131 @@
+package goauthlib
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/techpro-studio/gohttplib"
+	"net/http"
+	"time"
+)
+
+const CSRFHeader = "X-CSRF-Token"
+
+// CookieConfig describes cookies of browser apps. Token cookies are always HttpOnly,
+// CSRF cookie is readable by scripts, so they can send it back in X-CSRF-Token header.
+type CookieConfig struct {
+	TokenName   string
+	RefreshName string
+	CSRFName    string
+	Domain      string
+	Path        string
+	// RefreshPath limits the refresh cookie to refresh and logout routes.
+	RefreshPath string
+	Secure      bool
+	SameSite    http.SameSite
+	// MaxAge of cookies, zero makes session cookies.
+	MaxAge        time.Duration
+	RefreshMaxAge time.Duration
+}
+
+func NewCookieConfig() *CookieConfig {
+	return &CookieConfig{
+		TokenName:   "auth_token",
+		RefreshName: "auth_refresh",
+		CSRFName:    "csrf_token",
+		Path:        "/",
+		RefreshPath: "/auth",
+		Secure:      true,
+		SameSite:    http.SameSiteLaxMode,
+	}
+}
+
+func (c CookieConfig) cookie(name, value, path string, maxAge time.Duration, httpOnly bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   c.Domain,
+		Secure:   c.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.SameSite,
+	}
+	if maxAge > 0 {
+		cookie.MaxAge = int(maxAge.Seconds())
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+	return cookie
+}
+
+// SetTokens sets cookies of the login response with a new CSRF token.
+func (c CookieConfig) SetTokens(w http.ResponseWriter, response *Response) error {
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, c.cookie(c.TokenName, response.Token, c.Path, c.MaxAge, true))
+	if response.RefreshToken != "" {
+		http.SetCookie(w, c.cookie(c.RefreshName, response.RefreshToken, c.RefreshPath, c.RefreshMaxAge, true))
+	}
+	http.SetCookie(w, c.cookie(c.CSRFName, csrfToken, c.Path, c.MaxAge, false))
+	return nil
+}
+
+func (c CookieConfig) Clear(w http.ResponseWriter) {
+	http.SetCookie(w, c.cookie(c.TokenName, "", c.Path, 0, true))
+	http.SetCookie(w, c.cookie(c.RefreshName, "", c.RefreshPath, 0, true))
+	http.SetCookie(w, c.cookie(c.CSRFName, "", c.Path, 0, false))
+}
+
+func (c CookieConfig) Token(req *http.Request) string {
+	return cookieValue(req, c.TokenName)
+}
+
+func (c CookieConfig) RefreshToken(req *http.Request) string {
+	return cookieValue(req, c.RefreshName)
+}
+
+func cookieValue(req *http.Request, name string) string {
+	cookie, err := req.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+func newCSRFToken() (string, error) {
+	bytes := make([]byte, 32)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// CSRFMiddlewareFactory protects requests carrying the token or refresh cookie with double-submit token:
+// unsafe methods must send the value of CSRF cookie in X-CSRF-Token header. Requests with Authorization header
+// or without auth cookies can't be forged by other sites, they pass as is, so it can be a part of the default middleware.
+func CSRFMiddlewareFactory(cookie CookieConfig) gohttplib.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch req.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, req)
+				return
+			}
+			if req.Header.Get("Authorization") != "" || (cookie.Token(req) == "" && cookie.RefreshToken(req) == "") {
+				next.ServeHTTP(w, req)
+				return
+			}
+			expected := cookieValue(req, cookie.CSRFName)
+			actual := req.Header.Get(CSRFHeader)
+			if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) != 1 {
+				csrfTokenMismatch.Write(w)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
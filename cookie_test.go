@@ -0,0 +1,204 @@
+package goauthlib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestGetTokenFromRequestWithoutScheme(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("Authorization", "token-without-scheme")
+	if token := GetTokenFromRequest(req); token != "" {
+		t.Errorf("expected empty token, got %s", token)
+	}
+}
+
+func TestCookieTokens(t *testing.T) {
+	cookie := NewCookieConfig()
+	rec := httptest.NewRecorder()
+	if err := cookie.SetTokens(rec, &Response{Token: "access", RefreshToken: "refresh"}); err != nil {
+		t.Fatalf("SetTokens failed: %v", err)
+	}
+	cookies := map[string]*http.Cookie{}
+	for _, c := range rec.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	token := cookies[cookie.TokenName]
+	if token == nil || token.Value != "access" || !token.HttpOnly || !token.Secure || token.SameSite != http.SameSiteLaxMode {
+		t.Errorf("unexpected token cookie: %+v", token)
+	}
+	refresh := cookies[cookie.RefreshName]
+	if refresh == nil || refresh.Value != "refresh" || refresh.Path != cookie.RefreshPath {
+		t.Errorf("unexpected refresh cookie: %+v", refresh)
+	}
+	csrf := cookies[cookie.CSRFName]
+	if csrf == nil || csrf.Value == "" || csrf.HttpOnly {
+		t.Errorf("unexpected csrf cookie: %+v", csrf)
+	}
+
+	rec = httptest.NewRecorder()
+	cookie.Clear(rec)
+	for _, c := range rec.Result().Cookies() {
+		if c.MaxAge >= 0 || c.Value != "" {
+			t.Errorf("expected cookie %s to be cleared", c.Name)
+		}
+	}
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	cookie := NewCookieConfig()
+	handler := CSRFMiddlewareFactory(*cookie)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(method, csrfHeader, authorization string) int {
+		req := httptest.NewRequest(method, "/user/info", nil)
+		req.AddCookie(&http.Cookie{Name: cookie.TokenName, Value: "access"})
+		req.AddCookie(&http.Cookie{Name: cookie.CSRFName, Value: "csrf-value"})
+		if csrfHeader != "" {
+			req.Header.Set(CSRFHeader, csrfHeader)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request(http.MethodGet, "", ""); code != http.StatusOK {
+		t.Errorf("expected safe method to pass, got %d", code)
+	}
+	if code := request(http.MethodPatch, "", ""); code != http.StatusForbidden {
+		t.Errorf("expected 403 without csrf header, got %d", code)
+	}
+	if code := request(http.MethodPatch, "other", ""); code != http.StatusForbidden {
+		t.Errorf("expected 403 with wrong csrf header, got %d", code)
+	}
+	if code := request(http.MethodPatch, "csrf-value", ""); code != http.StatusOK {
+		t.Errorf("expected matching csrf header to pass, got %d", code)
+	}
+	if code := request(http.MethodPatch, "", "Bearer token"); code != http.StatusOK {
+		t.Errorf("expected header authenticated request to pass, got %d", code)
+	}
+
+	for _, path := range []string{"/auth/refresh", "/auth/logout"} {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.AddCookie(&http.Cookie{Name: cookie.RefreshName, Value: "refresh"})
+		req.AddCookie(&http.Cookie{Name: cookie.CSRFName, Value: "csrf-value"})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403 with only the refresh cookie, got %d", path, rec.Code)
+		}
+	}
+	req := httptest.NewRequest(http.MethodPost, "/auth/verify", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected request without auth cookies to pass, got %d", rec.Code)
+	}
+}
+
+func TestUserMiddlewareWithCookie(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	user := User{ID: bson.NewObjectID().Hex()}
+	token, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	cookie := NewCookieConfig()
+
+	var seen *User
+	handler := UserMiddlewareFactoryWithCookie(*jwtCfg, *cookie)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetUserFromRequest(r)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.AddCookie(&http.Cookie{Name: cookie.TokenName, Value: token})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen == nil || seen.ID != user.ID {
+		t.Fatalf("expected user from cookie, got %v", seen)
+	}
+}
+
+func TestLogoutHandler(t *testing.T) {
+	ctx := context.Background()
+	useCase, repository, sessions, user := newRefreshUseCase()
+	useCase.jwtConfig.SetRevocationStore(NewMemoryRevocationStore())
+	cookie := NewCookieConfig()
+	transport := NewTransport(useCase)
+	transport.SetCookieConfig(cookie)
+
+	login, err := useCase.generateResponseFor(ctx, user, nil, AuthMethodEmailCode)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	info, err := useCase.jwtConfig.ValidateToken(ctx, login.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: cookie.TokenName, Value: login.Token})
+	req.AddCookie(&http.Cookie{Name: cookie.RefreshName, Value: login.RefreshToken})
+	req.AddCookie(&http.Cookie{Name: cookie.CSRFName, Value: "csrf-value"})
+	rec := httptest.NewRecorder()
+	transport.LogoutHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	cleared := map[string]bool{}
+	for _, c := range rec.Result().Cookies() {
+		cleared[c.Name] = c.MaxAge < 0 && c.Value == ""
+	}
+	for _, name := range []string{cookie.TokenName, cookie.RefreshName, cookie.CSRFName} {
+		if !cleared[name] {
+			t.Errorf("expected cookie %s to be cleared", name)
+		}
+	}
+	if !sessions.sessions[info.SessionID].Revoked {
+		t.Error("expected the session to be revoked")
+	}
+	if len(repository.refreshTokens) != 0 {
+		t.Error("expected the refresh token to be revoked")
+	}
+	if _, err := useCase.jwtConfig.ValidateToken(ctx, login.Token); err == nil {
+		t.Error("expected the token to be rejected after logout")
+	}
+}
+
+func TestLogoutWithRefreshTokenInBody(t *testing.T) {
+	ctx := context.Background()
+	useCase, repository, sessions, user := newRefreshUseCase()
+	transport := NewTransport(useCase)
+
+	login, err := useCase.generateResponseFor(ctx, user, nil, AuthMethodEmailCode)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", strings.NewReader(`{"refresh_token": "`+login.RefreshToken+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+login.Token)
+	rec := httptest.NewRecorder()
+	transport.LogoutHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("expected no cookies without cookie mode")
+	}
+	if len(repository.refreshTokens) != 0 {
+		t.Error("expected the refresh token to be revoked")
+	}
+	for _, session := range sessions.sessions {
+		if !session.Revoked {
+			t.Error("expected the session to be revoked")
+		}
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"github.com/techpro-studio/goauthlib/oauth"
 	"github.com/techpro-studio/gohttplib"
-	"math/rand"
+	"slices"
+	"strings"
+	"time"
 )
 
 type OTPDelivery interface {
@@ -19,6 +21,8 @@ type UserCaseCallback interface {
 	OnUpdateUser(ctx context.Context, user *User)
 	OnAddService(ctx context.Context, user *User)
 	OnRemoveServiceFrom(ctx context.Context, user *User) error
+	OnGrantRole(ctx context.Context, user *User, role string)
+	OnRevokeRole(ctx context.Context, user *User, role string)
 }
 
 func DoNothingCallback() UserCaseCallback {
@@ -44,36 +48,99 @@ func (d DoNothingUseCaseCallback) OnRemoveServiceFrom(ctx context.Context, user
 	return nil
 }
 
+func (d DoNothingUseCaseCallback) OnGrantRole(ctx context.Context, user *User, role string) {
+}
+
+func (d DoNothingUseCaseCallback) OnRevokeRole(ctx context.Context, user *User, role string) {
+}
+
 type DefaultUseCase struct {
 	SocialProviders            map[string]oauth.SocialProvider
 	Deliveries                 map[string]OTPDelivery
+	magicLinkDeliveries        map[string]MagicLinkDelivery
 	repository                 Repository
 	callback                   UserCaseCallback
 	jwtConfig                  JWTConfig
 	softDeleteUserIfNoServices bool
+	auditLogger                AuditLogger
+	impersonationTTL           time.Duration
+	codeTTL                    time.Duration
+	maxCodeAttempts            int
+	counters                   CounterStore
+	sendQuota                  SendQuota
+	codeGenerator              CodeGenerator
+	codeSecret                 []byte
+	plaintextCodesUntil        time.Time
+	magicLinkURL               string
+	bindMagicLinkToDevice      bool
 }
 
 func (useCase *DefaultUseCase) SetSoftDeleteUserIfNoServices(softDeleteUserIfNoServices bool) {
 	useCase.softDeleteUserIfNoServices = softDeleteUserIfNoServices
 }
 
+func (useCase *DefaultUseCase) SetAuditLogger(auditLogger AuditLogger) {
+	useCase.auditLogger = auditLogger
+}
+
+// SetCodeTTL changes lifetime of sent codes, 10 minutes by default. Zero disables expiration.
+func (useCase *DefaultUseCase) SetCodeTTL(ttl time.Duration) {
+	useCase.codeTTL = ttl
+}
+
+// SetMaxCodeAttempts changes a number of checks allowed for a sent code, 5 by default. Zero disables the limit.
+func (useCase *DefaultUseCase) SetMaxCodeAttempts(maxCodeAttempts int) {
+	useCase.maxCodeAttempts = maxCodeAttempts
+}
+
+// SetCodeGenerator changes codes sent to users, 6 random digits by default.
+func (useCase *DefaultUseCase) SetCodeGenerator(codeGenerator CodeGenerator) {
+	useCase.codeGenerator = codeGenerator
+}
+
+// CodeSpec describes codes sent to the entity type, so they are validated before verification.
+func (useCase *DefaultUseCase) CodeSpec(entityType string) CodeSpec {
+	return useCase.codeGenerator.Spec(entityType)
+}
+
+// SetCodeSecret changes the key of HMAC of stored codes. The blinder of JWTConfig is used by default.
+func (useCase *DefaultUseCase) SetCodeSecret(secret []byte) {
+	useCase.codeSecret = secret
+}
+
+// SetPlaintextCodesUntil ends the migration window of codes stored in plaintext. By default it ends one default
+// code TTL after NewDefaultUseCase, when codes sent before the deploy are expired. Zero time rejects them at once.
+func (useCase *DefaultUseCase) SetPlaintextCodesUntil(until time.Time) {
+	useCase.plaintextCodesUntil = until
+}
+
+// SetSendQuota limits sending of codes, counters are kept in the store. Sending is not limited by default.
+func (useCase *DefaultUseCase) SetSendQuota(store CounterStore, quota SendQuota) {
+	useCase.counters = store
+	useCase.sendQuota = quota
+}
+
+// SetImpersonationTTL changes lifetime of impersonation tokens, 15 minutes by default.
+func (useCase *DefaultUseCase) SetImpersonationTTL(ttl time.Duration) {
+	useCase.impersonationTTL = ttl
+}
+
 func (useCase *DefaultUseCase) UpsertUser(ctx context.Context, entity AuthorizationEntity, info map[string]any) (*Response, error) {
 	user, err := useCase.repository.UpsertForEntity(ctx, entity, info)
 	if err != nil {
 		return nil, err
 	}
-	token, err := useCase.jwtConfig.GenerateTokenFromModel(*user)
-	if err != nil {
-		return nil, err
-	}
-	return &Response{
-		Token:    token,
-		User:     *user,
-		UserInfo: nil,
-	}, nil
+	return useCase.generateResponseFor(ctx, user, nil, "")
 }
 
 func (useCase *DefaultUseCase) PatchUserInfo(ctx context.Context, usr *User, body map[string]interface{}) (*User, error) {
+	usr, err := useCase.reloadUser(ctx, *usr)
+	if err != nil {
+		return nil, err
+	}
+	if usr.Info == nil {
+		usr.Info = map[string]any{}
+	}
 	for k, v := range body {
 		usr.Info[k] = v
 	}
@@ -81,12 +148,23 @@ func (useCase *DefaultUseCase) PatchUserInfo(ctx context.Context, usr *User, bod
 	return usr, nil
 }
 
+// reloadUser returns the stored user before it is changed. The user of the token is partial with slim claims,
+// saving it would drop entities and info which are not in the token.
+func (useCase *DefaultUseCase) reloadUser(ctx context.Context, user User) (*User, error) {
+	stored := useCase.repository.GetById(ctx, user.ID)
+	if stored == nil {
+		return nil, gohttplib.HTTP404(user.ID)
+	}
+	stored.Actor = user.Actor
+	return stored, nil
+}
+
 func (useCase *DefaultUseCase) RegisterOTPDelivery(key string, delivery OTPDelivery) {
 	useCase.Deliveries[key] = delivery
 }
 
 func NewDefaultUseCase(repository Repository, config JWTConfig, callback UserCaseCallback) *DefaultUseCase {
-	return &DefaultUseCase{repository: repository, SocialProviders: map[string]oauth.SocialProvider{}, Deliveries: map[string]OTPDelivery{}, jwtConfig: config, callback: callback}
+	return &DefaultUseCase{repository: repository, SocialProviders: map[string]oauth.SocialProvider{}, Deliveries: map[string]OTPDelivery{}, magicLinkDeliveries: map[string]MagicLinkDelivery{}, jwtConfig: config, callback: callback, codeTTL: defaultCodeTTL, maxCodeAttempts: defaultMaxCodeAttempts, codeGenerator: NewRandomCodeGenerator(DefaultCodeSpec()), codeSecret: []byte(config.Blinder()), plaintextCodesUntil: time.Now().Add(defaultCodeTTL)}
 }
 
 func (useCase *DefaultUseCase) RegisterSocialProvider(key string, provider oauth.SocialProvider) {
@@ -110,7 +188,7 @@ func (useCase *DefaultUseCase) AuthenticateViaSocialProvider(ctx context.Context
 	}
 	useCase.callback.OnSignUserWithSocial(ctx, usr, *result)
 	useCase.repository.SaveOAuthData(ctx, result)
-	return useCase.generateResponseFor(usr, result.Raw)
+	return useCase.generateResponseFor(ctx, usr, result.Raw, SocialAuthMethod(payload.Provider))
 }
 
 func (useCase *DefaultUseCase) appendNewEntitiesFromSocialToUserIfNeed(ctx context.Context, usr *User, result *oauth.ProviderResult) {
@@ -160,30 +238,231 @@ func (useCase *DefaultUseCase) getInfoFromProvider(ctx context.Context, payload
 	return providerResult, nil
 }
 
-func (useCase *DefaultUseCase) generateResponseFor(usr *User, userInfo map[string]interface{}) (*Response, error) {
-	jsonWebToken, err := useCase.jwtConfig.GenerateTokenFromModel(*usr)
+// generateResponseFor starts a new login. authMethod is empty when the user didn't authenticate, e.g. in UpsertUser.
+// A login with the step-up token of the same user continues its session with both methods.
+func (useCase *DefaultUseCase) generateResponseFor(ctx context.Context, usr *User, userInfo map[string]interface{}, authMethod string) (*Response, error) {
+	options := TokenOptions{KeyThumbprint: DPoPThumbprintFromContext(ctx)}
+	if authMethod != "" {
+		options.AuthMethods = []string{authMethod}
+		options.AuthTime = time.Now()
+	}
+	if previous := useCase.stepUpToken(ctx, usr); previous != nil && authMethod != "" {
+		options.SessionID = previous.SessionID
+		options.AuthMethods = stepUpMethods(previous.AuthMethods, authMethod)
+	} else {
+		sessionID, err := useCase.startSession(ctx, usr)
+		if err != nil {
+			return nil, err
+		}
+		options.SessionID = sessionID
+	}
+	return useCase.issueTokens(ctx, usr, userInfo, options)
+}
+
+// stepUpToken returns the valid step-up token of the user. Impersonation and exchanged tokens are never stepped up,
+// bound tokens only with a proof of their key.
+func (useCase *DefaultUseCase) stepUpToken(ctx context.Context, usr *User) *TokenInfo {
+	token := StepUpTokenFromContext(ctx)
+	if token == "" {
+		return nil
+	}
+	info, err := useCase.jwtConfig.ValidateToken(ctx, token)
+	if err != nil || info.User.ID != usr.ID || info.Actor != nil || info.IsScoped() {
+		return nil
+	}
+	if info.KeyThumbprint != "" && info.KeyThumbprint != DPoPThumbprintFromContext(ctx) {
+		return nil
+	}
+	return info
+}
+
+func (useCase *DefaultUseCase) issueTokens(ctx context.Context, usr *User, userInfo map[string]interface{}, options TokenOptions) (*Response, error) {
+	jsonWebToken, err := useCase.jwtConfig.GenerateToken(ctx, *usr, options)
+	if err != nil {
+		return nil, gohttplib.HTTP400(err.Error())
+	}
+	refreshToken, err := useCase.issueRefreshToken(ctx, usr, options)
 	if err != nil {
 		return nil, gohttplib.HTTP400(err.Error())
 	}
 	return &Response{
-		Token:    jsonWebToken,
-		User:     *usr,
-		UserInfo: userInfo,
+		Token:        jsonWebToken,
+		RefreshToken: refreshToken,
+		User:         *usr,
+		UserInfo:     userInfo,
 	}, nil
 }
 
+// startSession returns an error when the repository doesn't create the session, tokens without it would be rejected.
+func (useCase *DefaultUseCase) startSession(ctx context.Context, usr *User) (string, error) {
+	sessions := useCase.jwtConfig.SessionRepository()
+	if sessions == nil {
+		return "", nil
+	}
+	client := ClientInfoFromContext(ctx)
+	now := time.Now().Unix()
+	session := sessions.CreateSession(ctx, Session{
+		UserID:     usr.ID,
+		Device:     client.Device,
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	})
+	if session == nil || session.ID == "" {
+		return "", sessionNotCreated
+	}
+	return session.ID, nil
+}
+
+func (useCase *DefaultUseCase) issueRefreshToken(ctx context.Context, usr *User, options TokenOptions) (string, error) {
+	ttl := useCase.jwtConfig.RefreshTokenTTL()
+	if ttl <= 0 {
+		return "", nil
+	}
+	token, hash, err := GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	var authTime int64
+	if !options.AuthTime.IsZero() {
+		authTime = options.AuthTime.Unix()
+	}
+	useCase.repository.CreateRefreshToken(ctx, RefreshToken{
+		UserID:        usr.ID,
+		SessionID:     options.SessionID,
+		Hash:          hash,
+		ExpiresAt:     time.Now().Add(ttl).Unix(),
+		KeyThumbprint: options.KeyThumbprint,
+		AuthMethods:   options.AuthMethods,
+		AuthTime:      authTime,
+	})
+	return token, nil
+}
+
+func (useCase *DefaultUseCase) Refresh(ctx context.Context, refreshToken string) (*Response, error) {
+	stored := useCase.repository.ConsumeRefreshToken(ctx, HashRefreshToken(refreshToken))
+	if stored == nil || stored.ExpiresAt < time.Now().Unix() {
+		return nil, invalidRefreshToken
+	}
+	thumbprint := DPoPThumbprintFromContext(ctx)
+	if stored.KeyThumbprint != "" && stored.KeyThumbprint != thumbprint {
+		return nil, invalidRefreshToken
+	}
+	if sessions := useCase.jwtConfig.SessionRepository(); sessions != nil && stored.SessionID != "" {
+		session := sessions.GetSession(ctx, stored.SessionID)
+		if session == nil || session.Revoked {
+			return nil, sessionRevoked
+		}
+		sessions.TouchSession(ctx, session.ID, time.Now().Unix())
+	}
+	usr := useCase.repository.GetById(ctx, stored.UserID)
+	if usr == nil {
+		return nil, invalidRefreshToken
+	}
+	options := TokenOptions{SessionID: stored.SessionID, KeyThumbprint: thumbprint, AuthMethods: stored.AuthMethods}
+	if stored.AuthTime > 0 {
+		options.AuthTime = time.Unix(stored.AuthTime, 0)
+	}
+	return useCase.issueTokens(ctx, usr, usr.Info, options)
+}
+
+// Logout ends the session of the token. Invalid tokens are ignored, because the user is logged out anyway.
+// The refresh token is removed when given, so it is revoked even without sessions.
+func (useCase *DefaultUseCase) Logout(ctx context.Context, token string, refreshToken string) error {
+	var info *TokenInfo
+	if token != "" {
+		validated, err := useCase.jwtConfig.ValidateToken(ctx, token)
+		// Exchanged tokens share the session of the user, their holders must not end it.
+		if err == nil && !validated.IsScoped() {
+			info = validated
+		}
+	}
+	// Bound tokens end the session only with a proof of their key, like Refresh requires it.
+	if info != nil && info.KeyThumbprint != "" && info.KeyThumbprint != DPoPThumbprintFromContext(ctx) {
+		return invalidDPoPProof
+	}
+	if refreshToken != "" {
+		useCase.repository.ConsumeRefreshToken(ctx, HashRefreshToken(refreshToken))
+	}
+	if info == nil {
+		return nil
+	}
+	if sessions := useCase.jwtConfig.SessionRepository(); sessions != nil && info.SessionID != "" {
+		sessions.RevokeSession(ctx, info.User.ID, info.SessionID)
+	}
+	if useCase.jwtConfig.RevocationStore() != nil {
+		return useCase.jwtConfig.RevokeToken(ctx, info)
+	}
+	return nil
+}
+
+func (useCase *DefaultUseCase) sessionRepository() (SessionRepository, error) {
+	sessions := useCase.jwtConfig.SessionRepository()
+	if sessions == nil {
+		return nil, gohttplib.HTTP400("sessions are not enabled")
+	}
+	return sessions, nil
+}
+
+func (useCase *DefaultUseCase) ListSessions(ctx context.Context, user User) ([]*Session, error) {
+	sessions, err := useCase.sessionRepository()
+	if err != nil {
+		return nil, err
+	}
+	list := sessions.ListSessions(ctx, user.ID)
+	if current := TokenInfoFromContext(ctx); current != nil {
+		for _, session := range list {
+			session.Current = session.ID == current.SessionID
+		}
+	}
+	return list, nil
+}
+
+func (useCase *DefaultUseCase) RevokeSession(ctx context.Context, user User, sessionId string) error {
+	sessions, err := useCase.sessionRepository()
+	if err != nil {
+		return err
+	}
+	if !sessions.RevokeSession(ctx, user.ID, sessionId) {
+		return gohttplib.HTTP404(sessionId)
+	}
+	return nil
+}
+
+func (useCase *DefaultUseCase) RevokeAllSessions(ctx context.Context, user User) error {
+	sessions, err := useCase.sessionRepository()
+	if err != nil {
+		return err
+	}
+	sessions.RevokeAllSessions(ctx, user.ID)
+	useCase.repository.DeleteRefreshTokensForUser(ctx, user.ID)
+	return nil
+}
+
 func (useCase *DefaultUseCase) VerifyDelete(ctx context.Context, user User, code string) error {
-	verification := useCase.repository.GetServiceActionVerification(ctx, deleteAccountAction)
-	if verification.Code != code {
-		return gohttplib.HTTP400("code is not equal")
+	verification := useCase.repository.GetServiceActionVerification(ctx, DeleteAccountAction)
+	if verification == nil {
+		return gohttplib.HTTP404(DeleteAccountAction)
 	}
+	err := useCase.checkVerification(ctx, verification, code)
+	if err != nil {
+		return err
+	}
+	useCase.repository.DeleteVerification(ctx, verification.ID)
 	return useCase.ForceDelete(ctx, user)
 }
 
 func (useCase *DefaultUseCase) ForceDelete(ctx context.Context, user User) error {
+	stored, err := useCase.reloadUser(ctx, user)
+	if err != nil {
+		return err
+	}
+	user = *stored
 	useCase.repository.RemoveService(ctx, user.ID, useCase.softDeleteUserIfNoServices, func(ctx context.Context, userId string) error {
 		return useCase.callback.OnRemoveServiceFrom(ctx, &user)
 	})
+	useCase.repository.DeleteRefreshTokensForUser(ctx, user.ID)
 	for _, entity := range user.Entities {
 		provider := useCase.SocialProviders[entity.Type]
 		if provider != nil {
@@ -194,14 +473,30 @@ func (useCase *DefaultUseCase) ForceDelete(ctx context.Context, user User) error
 		}
 	}
 
-	return nil
+	return useCase.revokeCallerTokens(ctx, user)
+}
+
+// revokeCallerTokens revokes the token of the current request and all sessions of the user.
+func (useCase *DefaultUseCase) revokeCallerTokens(ctx context.Context, user User) error {
+	if sessions := useCase.jwtConfig.SessionRepository(); sessions != nil {
+		sessions.RevokeAllSessions(ctx, user.ID)
+	}
+	current := TokenInfoFromContext(ctx)
+	if current == nil || current.User.ID != user.ID || useCase.jwtConfig.RevocationStore() == nil {
+		return nil
+	}
+	return useCase.jwtConfig.RevokeToken(ctx, current)
 }
 
 func (useCase *DefaultUseCase) SendVerificationCode(ctx context.Context, user User, action string) error {
 	errs := []error{}
-	code := fmt.Sprintf("%d", rand.Intn(899999)+100000)
+	// Codes of service actions are sent by email.
+	code, err := useCase.codeGenerator.Generate(EntityTypeEmail)
+	if err != nil {
+		return err
+	}
 	delivery := useCase.Deliveries[EntityTypeEmail]
-	useCase.repository.CreateServiceActionVerification(ctx, action, code)
+	useCase.repository.CreateServiceActionVerification(ctx, action, useCase.hashCode(code))
 	for _, entity := range user.Entities {
 		if entity.Type == EntityTypeEmail {
 			err := delivery.SendOTP(ctx, entity.Value, code)
@@ -214,14 +509,20 @@ func (useCase *DefaultUseCase) SendVerificationCode(ctx context.Context, user Us
 }
 
 func (useCase *DefaultUseCase) SendCode(ctx context.Context, entity AuthorizationEntity) error {
-	code := fmt.Sprintf("%d", rand.Intn(899999)+100000)
 	dataDelivery := useCase.Deliveries[entity.Type]
 	if dataDelivery == nil {
 		return gohttplib.HTTP400("type not found")
 	}
-	useCase.repository.CreateVerificationForEntity(ctx, entity, code)
-	err := dataDelivery.SendOTP(ctx, entity.Value, code)
-	return err
+	err := useCase.checkSendQuota(ctx, entity)
+	if err != nil {
+		return err
+	}
+	code, err := useCase.codeGenerator.Generate(entity.Type)
+	if err != nil {
+		return err
+	}
+	useCase.repository.CreateVerificationForEntity(ctx, entity, useCase.hashCode(code))
+	return dataDelivery.SendOTP(ctx, entity.Value, code)
 }
 
 func (useCase *DefaultUseCase) SendCodeWithUser(ctx context.Context, user User, entity AuthorizationEntity) error {
@@ -239,6 +540,11 @@ func (useCase *DefaultUseCase) AuthenticateWithCode(ctx context.Context, entity
 	if err != nil {
 		return nil, err
 	}
+	return useCase.authenticateEntity(ctx, entity, verification, authMethodForEntity(entity))
+}
+
+// authenticateEntity logs in or signs up the owner of the entity once the verification is passed.
+func (useCase *DefaultUseCase) authenticateEntity(ctx context.Context, entity AuthorizationEntity, verification *Verification, authMethod string) (*Response, error) {
 	usr := useCase.repository.GetForEntity(ctx, entity)
 	if usr == nil {
 		usr = useCase.repository.CreateForEntity(ctx, entity)
@@ -247,7 +553,7 @@ func (useCase *DefaultUseCase) AuthenticateWithCode(ctx context.Context, entity
 		useCase.repository.EnsureService(ctx, usr.ID)
 	}
 	useCase.repository.DeleteVerification(ctx, verification.ID)
-	return useCase.generateResponseFor(usr, usr.Info)
+	return useCase.generateResponseFor(ctx, usr, usr.Info, authMethod)
 }
 
 func (useCase *DefaultUseCase) getVerificationAndCompare(ctx context.Context, entity AuthorizationEntity, code string) (*Verification, error) {
@@ -255,8 +561,9 @@ func (useCase *DefaultUseCase) getVerificationAndCompare(ctx context.Context, en
 	if verification == nil {
 		return nil, gohttplib.HTTP404(entity.Value)
 	}
-	if verification.Code != code {
-		return nil, invalidCode
+	err := useCase.checkVerification(ctx, verification, code)
+	if err != nil {
+		return nil, err
 	}
 	return verification, nil
 }
@@ -272,6 +579,11 @@ func (useCase *DefaultUseCase) foundEntityInUser(user User, entity Authorization
 }
 
 func (useCase *DefaultUseCase) RemoveAuthenticationEntity(ctx context.Context, user User, entity AuthorizationEntity) error {
+	stored, err := useCase.reloadUser(ctx, user)
+	if err != nil {
+		return err
+	}
+	user = *stored
 	foundIdx := useCase.foundEntityInUser(user, entity)
 	if foundIdx == -1 {
 		return gohttplib.HTTP404(entity.Value)
@@ -283,14 +595,30 @@ func (useCase *DefaultUseCase) RemoveAuthenticationEntity(ctx context.Context, u
 	usrEntities = append(usrEntities[:foundIdx], usrEntities[foundIdx+1:]...)
 	user.Entities = usrEntities
 	useCase.repository.Save(ctx, &user)
+	useCase.bumpTokenVersion(ctx, &user)
+	return nil
+}
+
+func (useCase *DefaultUseCase) InvalidateTokens(ctx context.Context, user User) error {
+	useCase.bumpTokenVersion(ctx, &user)
 	return nil
 }
 
+// bumpTokenVersion invalidates all access and refresh tokens of the user.
+func (useCase *DefaultUseCase) bumpTokenVersion(ctx context.Context, user *User) {
+	user.TokenVersion = useCase.repository.IncrementTokenVersion(ctx, user.ID)
+	useCase.repository.DeleteRefreshTokensForUser(ctx, user.ID)
+}
+
 func (useCase *DefaultUseCase) AddSocialAuthenticationEntity(ctx context.Context, user *User, payload SocialProviderPayload) (*User, error) {
 	result, err := useCase.getInfoFromProvider(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
+	user, err = useCase.reloadUser(ctx, *user)
+	if err != nil {
+		return nil, err
+	}
 	usrAttached := useCase.repository.GetForSocial(ctx, result)
 	if usrAttached != nil {
 		if usrAttached.ID == user.ID {
@@ -301,10 +629,15 @@ func (useCase *DefaultUseCase) AddSocialAuthenticationEntity(ctx context.Context
 	}
 	useCase.appendNewEntitiesFromSocialToUserIfNeed(ctx, user, result)
 	useCase.repository.SaveOAuthData(ctx, result)
+	useCase.bumpTokenVersion(ctx, user)
 	return user, nil
 }
 
 func (useCase *DefaultUseCase) VerifyAuthenticationEntity(ctx context.Context, user *User, entity AuthorizationEntity, code string) (*User, error) {
+	user, err := useCase.reloadUser(ctx, *user)
+	if err != nil {
+		return nil, err
+	}
 	usrAttached := useCase.repository.GetForEntity(ctx, entity)
 	if usrAttached != nil {
 		if usrAttached.ID == user.ID {
@@ -380,3 +713,216 @@ func (useCase *DefaultUseCase) ExtractAvatarUrlFromSocialProvider(ctx context.Co
 	}
 	return nil
 }
+
+// Introspect validates the token the same way as user middleware does, including revocation and sessions.
+// Any invalid token is reported as inactive.
+func (useCase *DefaultUseCase) Introspect(ctx context.Context, token string) (*IntrospectionResponse, error) {
+	info, err := useCase.jwtConfig.ValidateToken(ctx, token)
+	if err != nil {
+		return &IntrospectionResponse{Active: false}, nil
+	}
+	usr := useCase.repository.GetById(ctx, info.User.ID)
+	if usr == nil {
+		return &IntrospectionResponse{Active: false}, nil
+	}
+	response := IntrospectionResponse{
+		Active:    true,
+		Subject:   usr.ID,
+		TokenID:   info.ID,
+		SessionID: info.SessionID,
+		TokenType: "Bearer",
+		Entities:  usr.Entities,
+	}
+	if info.Actor != nil {
+		response.Actor = map[string]string{"sub": info.Actor.ID}
+	}
+	if info.KeyThumbprint != "" {
+		response.TokenType = "DPoP"
+		response.Confirmation = map[string]string{"jkt": info.KeyThumbprint}
+	}
+	if !info.ExpiresAt.IsZero() {
+		response.ExpiresAt = info.ExpiresAt.Unix()
+	}
+	if iat, ok := info.Claims["iat"].(float64); ok {
+		response.IssuedAt = int64(iat)
+	}
+	response.Issuer, _ = info.Claims["iss"].(string)
+	response.Scope = strings.Join(info.Scope, " ")
+	response.ClientID = info.ClientID
+	switch aud := info.Claims["aud"].(type) {
+	case string:
+		response.Audience = []string{aud}
+	case []any:
+		for _, item := range aud {
+			if str, ok := item.(string); ok {
+				response.Audience = append(response.Audience, str)
+			}
+		}
+	}
+	return &response, nil
+}
+
+func (useCase *DefaultUseCase) audit() AuditLogger {
+	if useCase.auditLogger == nil {
+		return LogAuditLogger{}
+	}
+	return useCase.auditLogger
+}
+
+// StartImpersonation issues a short-lived token of the target user with act claim of the admin.
+// No refresh token is issued, admin has to start impersonation again when the token expires.
+func (useCase *DefaultUseCase) StartImpersonation(ctx context.Context, admin User, request ImpersonationRequest) (*Response, error) {
+	if admin.Actor != nil {
+		return nil, impersonationForbidden
+	}
+	if admin.ID == request.UserID {
+		return nil, gohttplib.HTTP400("can't impersonate yourself")
+	}
+	target := useCase.repository.GetById(ctx, request.UserID)
+	if target == nil {
+		return nil, gohttplib.HTTP404(request.UserID)
+	}
+	ttl := useCase.impersonationTTL
+	if ttl <= 0 {
+		ttl = defaultImpersonationTTL
+	}
+	sessionID, err := useCase.startSession(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	token, err := useCase.jwtConfig.GenerateToken(ctx, *target, TokenOptions{
+		SessionID: sessionID,
+		Actor:     &Actor{ID: admin.ID, ReadOnly: request.ReadOnly},
+		TTL:       ttl,
+	})
+	if err != nil {
+		return nil, gohttplib.HTTP400(err.Error())
+	}
+	info, err := useCase.jwtConfig.ParseToken(token)
+	if err != nil {
+		return nil, gohttplib.HTTP400(err.Error())
+	}
+	useCase.audit().LogImpersonation(ctx, ImpersonationEvent{
+		Action:   ImpersonationStarted,
+		ActorID:  admin.ID,
+		UserID:   target.ID,
+		TokenID:  info.ID,
+		ReadOnly: request.ReadOnly,
+		Reason:   request.Reason,
+		Time:     time.Now(),
+	})
+	return &Response{Token: token, User: *target}, nil
+}
+
+// StopImpersonation revokes the current impersonation token and its session when they can be revoked.
+func (useCase *DefaultUseCase) StopImpersonation(ctx context.Context, user User) error {
+	info := TokenInfoFromContext(ctx)
+	if info == nil || info.Actor == nil {
+		return gohttplib.HTTP400("not an impersonation token")
+	}
+	if useCase.jwtConfig.RevocationStore() != nil {
+		err := useCase.jwtConfig.RevokeToken(ctx, info)
+		if err != nil {
+			return err
+		}
+	}
+	if sessions := useCase.jwtConfig.SessionRepository(); sessions != nil && info.SessionID != "" {
+		sessions.RevokeSession(ctx, user.ID, info.SessionID)
+	}
+	useCase.audit().LogImpersonation(ctx, ImpersonationEvent{
+		Action:   ImpersonationStopped,
+		ActorID:  info.Actor.ID,
+		UserID:   user.ID,
+		TokenID:  info.ID,
+		ReadOnly: info.Actor.ReadOnly,
+		Time:     time.Now(),
+	})
+	return nil
+}
+
+// ExchangeToken trades the user token presented by the client for a down-scoped token of another audience.
+// The new token keeps session, authentication context, actor and DPoP binding of the subject token
+// and never outlives it.
+func (useCase *DefaultUseCase) ExchangeToken(ctx context.Context, client ServiceClient, request TokenExchangeRequest) (*TokenExchangeResponse, error) {
+	subject, err := useCase.jwtConfig.ValidateToken(ctx, request.SubjectToken)
+	if err != nil {
+		return nil, invalidGrant
+	}
+	if !slices.Contains(client.Audiences, request.Audience) {
+		return nil, invalidTarget
+	}
+	scope, ok := exchangeScope(request.Scope, subject.Scope, client.Scopes)
+	if !ok {
+		return nil, invalidScope
+	}
+	usr := useCase.repository.GetById(ctx, subject.User.ID)
+	if usr == nil {
+		return nil, invalidGrant
+	}
+	ttl := useCase.jwtConfig.AccessTokenTTL()
+	if !subject.ExpiresAt.IsZero() {
+		remaining := time.Until(subject.ExpiresAt)
+		if ttl <= 0 || remaining < ttl {
+			ttl = remaining
+		}
+	}
+	token, err := useCase.jwtConfig.GenerateToken(ctx, *usr, TokenOptions{
+		SessionID:     subject.SessionID,
+		KeyThumbprint: subject.KeyThumbprint,
+		AuthMethods:   subject.AuthMethods,
+		AuthTime:      subject.AuthTime,
+		Actor:         subject.Actor,
+		TTL:           ttl,
+		Audience:      []string{request.Audience},
+		Scope:         scope,
+		ClientID:      client.ID,
+	})
+	if err != nil {
+		return nil, gohttplib.HTTP400(err.Error())
+	}
+	return &TokenExchangeResponse{
+		AccessToken:     token,
+		IssuedTokenType: AccessTokenType,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(ttl.Seconds()),
+		Scope:           strings.Join(scope, " "),
+	}, nil
+}
+
+// GrantRole adds the role in the current service. Token version is bumped, so tokens with old roles stop working
+// when JWTConfig has TokenVersionSource. Without it they keep old roles until they expire.
+func (useCase *DefaultUseCase) GrantRole(ctx context.Context, userId string, role string) (*User, error) {
+	usr := useCase.repository.GetById(ctx, userId)
+	if usr == nil {
+		return nil, gohttplib.HTTP404(userId)
+	}
+	if !useCase.repository.GrantRole(ctx, userId, role) {
+		return usr, nil
+	}
+	useCase.bumpTokenVersion(ctx, usr)
+	usr = useCase.repository.GetById(ctx, userId)
+	if usr == nil {
+		return nil, gohttplib.HTTP404(userId)
+	}
+	useCase.callback.OnGrantRole(ctx, usr, role)
+	return usr, nil
+}
+
+// RevokeRole removes the role in the current service. Token version is bumped, so tokens with old roles stop working
+// when JWTConfig has TokenVersionSource. Without it they keep old roles until they expire.
+func (useCase *DefaultUseCase) RevokeRole(ctx context.Context, userId string, role string) (*User, error) {
+	usr := useCase.repository.GetById(ctx, userId)
+	if usr == nil {
+		return nil, gohttplib.HTTP404(userId)
+	}
+	if !useCase.repository.RevokeRole(ctx, userId, role) {
+		return usr, nil
+	}
+	useCase.bumpTokenVersion(ctx, usr)
+	usr = useCase.repository.GetById(ctx, userId)
+	if usr == nil {
+		return nil, gohttplib.HTTP404(userId)
+	}
+	useCase.callback.OnRevokeRole(ctx, usr, role)
+	return usr, nil
+}
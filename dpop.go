@@ -0,0 +1,227 @@
+package goauthlib
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/techpro-studio/gohttplib"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DPoPHeader = "DPoP"
+const DPoPThumbprintContextKey = "dpop_thumbprint_key"
+
+const dpopProofType = "dpop+jwt"
+
+// dpopSigningMethods are asymmetric algorithms accepted in proofs (RFC 9449).
+var dpopSigningMethods = []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "EdDSA"}
+
+// ReplayCache remembers ids of used DPoP proofs.
+type ReplayCache interface {
+	// Seen records the id and reports whether it was recorded before. The id may be forgotten after expiresAt.
+	Seen(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+}
+
+// MemoryReplayCache is a ReplayCache for a single instance deployments and tests.
+type MemoryReplayCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{seen: map[string]time.Time{}}
+}
+
+func (c *MemoryReplayCache) Seen(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for key, exp := range c.seen {
+		if exp.Before(now) {
+			delete(c.seen, key)
+		}
+	}
+	if _, ok := c.seen[id]; ok {
+		return true, nil
+	}
+	c.seen[id] = expiresAt
+	return false, nil
+}
+
+// DPoPVerifier checks DPoP proofs. Proofs older than maxAge or issued more than maxAge in the future are rejected,
+// used proofs are kept in the replay cache for the same time.
+type DPoPVerifier struct {
+	replay  ReplayCache
+	maxAge  time.Duration
+	proxies *TrustedProxies
+}
+
+func NewDPoPVerifier(replay ReplayCache, maxAge time.Duration) *DPoPVerifier {
+	return &DPoPVerifier{replay: replay, maxAge: maxAge}
+}
+
+// SetTrustedProxies enables X-Forwarded-Proto of requests coming from the proxies, see DPoPRequestURL.
+func (verifier *DPoPVerifier) SetTrustedProxies(proxies *TrustedProxies) {
+	verifier.proxies = proxies
+}
+
+type dpopClaims struct {
+	HTM string `json:"htm"`
+	HTU string `json:"htu"`
+	ATH string `json:"ath,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Verify checks the proof for the method and url and returns thumbprint of its key.
+// accessToken is empty when the proof is sent to the login endpoints.
+func (verifier *DPoPVerifier) Verify(ctx context.Context, proof, method, uri, accessToken string) (string, error) {
+	var jwk JWK
+	claims := dpopClaims{}
+	_, err := jwt.ParseWithClaims(proof, &claims, func(token *jwt.Token) (any, error) {
+		if token.Header["typ"] != dpopProofType {
+			return nil, errors.New("invalid proof type")
+		}
+		header, ok := token.Header["jwk"].(map[string]any)
+		if !ok {
+			return nil, errors.New("proof has no jwk")
+		}
+		if _, ok := header["d"]; ok {
+			return nil, errors.New("proof contains private key")
+		}
+		raw, err := json.Marshal(header)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(raw, &jwk)
+		if err != nil {
+			return nil, err
+		}
+		return jwk.PublicKey()
+	}, jwt.WithValidMethods(dpopSigningMethods))
+	if err != nil {
+		return "", err
+	}
+
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return "", errors.New("proof has no jti or iat")
+	}
+	age := time.Since(claims.IssuedAt.Time)
+	if age > verifier.maxAge || age < -verifier.maxAge {
+		return "", errors.New("proof is expired")
+	}
+	if !strings.EqualFold(claims.HTM, method) {
+		return "", errors.New("proof method mismatch")
+	}
+	htu, err := normalizeHTU(claims.HTU)
+	if err != nil {
+		return "", err
+	}
+	expected, err := normalizeHTU(uri)
+	if err != nil {
+		return "", err
+	}
+	if htu != expected {
+		return "", errors.New("proof url mismatch")
+	}
+	if accessToken != "" {
+		hash := sha256.Sum256([]byte(accessToken))
+		if claims.ATH != base64.RawURLEncoding.EncodeToString(hash[:]) {
+			return "", errors.New("proof access token hash mismatch")
+		}
+	}
+	seen, err := verifier.replay.Seen(ctx, claims.ID, claims.IssuedAt.Add(verifier.maxAge))
+	if err != nil {
+		return "", err
+	}
+	if seen {
+		return "", errors.New("proof is replayed")
+	}
+	return jwk.Thumbprint()
+}
+
+// VerifyRequest checks the single DPoP header of the request.
+func (verifier *DPoPVerifier) VerifyRequest(req *http.Request, accessToken string) (string, error) {
+	proofs := req.Header.Values(DPoPHeader)
+	if len(proofs) != 1 {
+		return "", errors.New("exactly one DPoP proof is required")
+	}
+	return verifier.Verify(req.Context(), proofs[0], req.Method, DPoPRequestURL(req, verifier.proxies), accessToken)
+}
+
+// DPoPRequestURL is the url the proof must be issued for. X-Forwarded-Proto of TLS terminating proxies is honored
+// only when the request comes from one of proxies, nil proxies trusts nobody.
+func DPoPRequestURL(req *http.Request, proxies *TrustedProxies) string {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	if proto := req.Header.Get("X-Forwarded-Proto"); proto != "" && proxies.trusts(hostOf(req.RemoteAddr)) {
+		scheme = proto
+	}
+	return scheme + "://" + req.Host + req.URL.Path
+}
+
+// normalizeHTU drops query and fragment, which are not compared by RFC 9449.
+func normalizeHTU(raw string) (string, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	return parsed.String(), nil
+}
+
+func WithDPoPThumbprint(ctx context.Context, thumbprint string) context.Context {
+	return context.WithValue(ctx, DPoPThumbprintContextKey, thumbprint)
+}
+
+// DPoPThumbprintFromContext is empty when the request had no DPoP proof.
+func DPoPThumbprintFromContext(ctx context.Context) string {
+	thumbprint, _ := ctx.Value(DPoPThumbprintContextKey).(string)
+	return thumbprint
+}
+
+// DPoPMiddlewareFactory is used for login and refresh routes. When the request has a DPoP proof,
+// issued tokens are bound to its key. Requests without proof get plain bearer tokens.
+func DPoPMiddlewareFactory(config JWTConfig) gohttplib.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			verifier := config.DPoPVerifier()
+			if verifier == nil || req.Header.Get(DPoPHeader) == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+			thumbprint, err := verifier.VerifyRequest(req, "")
+			if err != nil {
+				invalidDPoPProof.Write(w)
+				return
+			}
+			next.ServeHTTP(w, req.WithContext(WithDPoPThumbprint(req.Context(), thumbprint)))
+		})
+	}
+}
+
+// checkDPoP requires a proof of possession for tokens bound to a key.
+func (config JWTConfig) checkDPoP(req *http.Request, token string, info *TokenInfo) error {
+	if info.KeyThumbprint == "" {
+		return nil
+	}
+	if config.dpop == nil {
+		return invalidDPoPProof
+	}
+	thumbprint, err := config.dpop.VerifyRequest(req, token)
+	if err != nil || thumbprint != info.KeyThumbprint {
+		return invalidDPoPProof
+	}
+	return nil
+}
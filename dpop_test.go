@@ -0,0 +1,187 @@
+package goauthlib
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func newDPoPProof(t *testing.T, key *ecdsa.PrivateKey, method, uri, accessToken string) string {
+	t.Helper()
+	jwk, err := NewJWK("", "", &key.PublicKey)
+	if err != nil {
+		t.Fatalf("NewJWK failed: %v", err)
+	}
+	header := map[string]any{}
+	raw, _ := json.Marshal(JWK{Kty: jwk.Kty, Crv: jwk.Crv, X: jwk.X, Y: jwk.Y})
+	_ = json.Unmarshal(raw, &header)
+
+	jti, _ := newTokenId()
+	claims := jwt.MapClaims{"jti": jti, "htm": method, "htu": uri, "iat": time.Now().Unix()}
+	if accessToken != "" {
+		hash := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(hash[:])
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["typ"] = dpopProofType
+	token.Header["jwk"] = header
+	proof, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign proof: %v", err)
+	}
+	return proof
+}
+
+func TestDPoPVerifier(t *testing.T) {
+	ctx := context.Background()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	verifier := NewDPoPVerifier(NewMemoryReplayCache(), time.Minute)
+
+	proof := newDPoPProof(t, key, "POST", "https://auth.example.com/auth/refresh", "")
+	thumbprint, err := verifier.Verify(ctx, proof, "POST", "https://auth.example.com/auth/refresh?x=1", "")
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	jwk, _ := NewJWK("", "", &key.PublicKey)
+	expected, _ := jwk.Thumbprint()
+	if thumbprint != expected {
+		t.Errorf("expected thumbprint %s, got %s", expected, thumbprint)
+	}
+	if _, err := verifier.Verify(ctx, proof, "POST", "https://auth.example.com/auth/refresh", ""); err == nil {
+		t.Error("expected replayed proof to be rejected")
+	}
+
+	proof = newDPoPProof(t, key, "GET", "https://auth.example.com/auth/refresh", "")
+	if _, err := verifier.Verify(ctx, proof, "POST", "https://auth.example.com/auth/refresh", ""); err == nil {
+		t.Error("expected method mismatch to be rejected")
+	}
+	proof = newDPoPProof(t, key, "POST", "https://other.example.com/auth/refresh", "")
+	if _, err := verifier.Verify(ctx, proof, "POST", "https://auth.example.com/auth/refresh", ""); err == nil {
+		t.Error("expected url mismatch to be rejected")
+	}
+	proof = newDPoPProof(t, key, "GET", "https://api.example.com/me", "token-a")
+	if _, err := verifier.Verify(ctx, proof, "GET", "https://api.example.com/me", "token-b"); err == nil {
+		t.Error("expected access token hash mismatch to be rejected")
+	}
+}
+
+func TestJWKPublicKeyRoundTrip(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	jwk, err := NewJWK("", "", &key.PublicKey)
+	if err != nil {
+		t.Fatalf("NewJWK failed: %v", err)
+	}
+	publicKey, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatalf("PublicKey failed: %v", err)
+	}
+	if !key.PublicKey.Equal(publicKey) {
+		t.Error("expected the same public key")
+	}
+}
+
+func TestUserMiddlewareWithDPoP(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwk, _ := NewJWK("", "", &key.PublicKey)
+	thumbprint, _ := jwk.Thumbprint()
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetDPoPVerifier(NewDPoPVerifier(NewMemoryReplayCache(), time.Minute))
+	token, err := jwtCfg.GenerateToken(context.Background(), User{ID: bson.NewObjectID().Hex()}, TokenOptions{KeyThumbprint: thumbprint})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	handler := UserMiddlewareFactory(*jwtCfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com/me", nil)
+	req.Header.Set("Authorization", "DPoP "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without proof, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "https://api.example.com/me", nil)
+	req.Header.Set("Authorization", "DPoP "+token)
+	req.Header.Set(DPoPHeader, newDPoPProof(t, key, http.MethodGet, "https://api.example.com/me", token))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 with proof, got %d", rec.Code)
+	}
+
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	req = httptest.NewRequest(http.MethodGet, "https://api.example.com/me", nil)
+	req.Header.Set("Authorization", "DPoP "+token)
+	req.Header.Set(DPoPHeader, newDPoPProof(t, other, http.MethodGet, "https://api.example.com/me", token))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with proof of another key, got %d", rec.Code)
+	}
+}
+
+func TestDPoPRequestURL(t *testing.T) {
+	proxies, err := NewTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("NewTrustedProxies failed: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "http://auth.example.com/auth/refresh", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	req.RemoteAddr = "203.0.113.7:5000"
+	if url := DPoPRequestURL(req, proxies); url != "http://auth.example.com/auth/refresh" {
+		t.Errorf("expected X-Forwarded-Proto of untrusted client to be ignored, got %s", url)
+	}
+	req.RemoteAddr = "10.0.0.2:5000"
+	if url := DPoPRequestURL(req, nil); url != "http://auth.example.com/auth/refresh" {
+		t.Errorf("expected X-Forwarded-Proto to be ignored without proxies, got %s", url)
+	}
+	if url := DPoPRequestURL(req, proxies); url != "https://auth.example.com/auth/refresh" {
+		t.Errorf("expected X-Forwarded-Proto of trusted proxy, got %s", url)
+	}
+}
+
+func TestLogoutWithDPoPBoundToken(t *testing.T) {
+	ctx := context.Background()
+	useCase, _, sessions, user := newRefreshUseCase()
+	bound := WithDPoPThumbprint(ctx, "client-key")
+	login, err := useCase.generateResponseFor(bound, user, nil, AuthMethodEmailCode)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	info, err := useCase.jwtConfig.ParseToken(login.Token)
+	if err != nil {
+		t.Fatalf("ParseToken failed: %v", err)
+	}
+
+	if err := useCase.Logout(ctx, login.Token, ""); err == nil || err.Error() != invalidDPoPProof.Error() {
+		t.Fatalf("expected INVALID_DPOP_PROOF without proof, got %v", err)
+	}
+	if err := useCase.Logout(WithDPoPThumbprint(ctx, "another-key"), login.Token, ""); err == nil || err.Error() != invalidDPoPProof.Error() {
+		t.Fatalf("expected INVALID_DPOP_PROOF for another key, got %v", err)
+	}
+	if sessions.sessions[info.SessionID].Revoked {
+		t.Fatal("expected the session to stay without proof")
+	}
+	if err := useCase.Logout(bound, login.Token, ""); err != nil {
+		t.Fatalf("Logout with proof failed: %v", err)
+	}
+	if !sessions.sessions[info.SessionID].Revoked {
+		t.Error("expected the session to be revoked")
+	}
+}
@@ -6,3 +6,25 @@ var entityAlreadyExists = gohttplib.NewServerError(403, "ALREADY_EXISTS", "Entit
 var entityHasAlreadyUser = gohttplib.NewServerError(403, "HAS_ALREADY_USER", "Entity has already user", "codee", nil)
 var cantDeleteLastEntity = gohttplib.NewServerError(403, "CANT_DELETE_LAST", "Can't delete last entity", "codee", nil)
 var invalidCode = gohttplib.NewServerError(403, "INVALID_CODE", "Invalid code", "codee", nil)
+var invalidCodeFormat = gohttplib.NewServerError(400, "INVALID_CODE_FORMAT", "Code has invalid format", "code", nil)
+var invalidMagicLink = gohttplib.NewServerError(401, "INVALID_MAGIC_LINK", "Magic link is invalid or used", "token", nil)
+var magicLinkConfirmationRequired = gohttplib.NewServerError(403, "CONFIRMATION_REQUIRED", "Link is opened on another device, enter the code shown on the device which requested it", "confirmation_code", nil)
+var invalidConfirmationCode = gohttplib.NewServerError(403, "INVALID_CONFIRMATION_CODE", "Invalid confirmation code", "confirmation_code", nil)
+var codeExpired = gohttplib.NewServerError(403, "CODE_EXPIRED", "Code is expired", "code", nil)
+var tooManyAttempts = gohttplib.NewServerError(429, "TOO_MANY_ATTEMPTS", "Too many attempts, request a new code", "code", nil)
+var invalidRefreshToken = gohttplib.NewServerError(401, "INVALID_REFRESH_TOKEN", "Invalid refresh token", "refresh_token", nil)
+var sessionNotCreated = gohttplib.NewServerError(500, "SESSION_NOT_CREATED", "Session is not created", "token", nil)
+var sessionRevoked = gohttplib.NewServerError(401, "SESSION_REVOKED", "Session is revoked", "token", nil)
+var tokenRevoked = gohttplib.NewServerError(401, "TOKEN_REVOKED", "Token is revoked", "token", nil)
+var tokenVersionOutdated = gohttplib.NewServerError(401, "TOKEN_OUTDATED", "Token is outdated", "token", nil)
+var invalidDPoPProof = gohttplib.NewServerError(401, "INVALID_DPOP_PROOF", "Invalid DPoP proof", "dpop", nil)
+var impersonationForbidden = gohttplib.NewServerError(403, "IMPERSONATION_FORBIDDEN", "Action is not allowed while impersonating", "token", nil)
+var impersonationReadOnly = gohttplib.NewServerError(403, "IMPERSONATION_READ_ONLY", "Impersonation token is read only", "token", nil)
+var invalidGrant = gohttplib.NewServerError(400, "INVALID_GRANT", "Subject token is invalid", "subject_token", nil)
+var invalidTarget = gohttplib.NewServerError(400, "INVALID_TARGET", "Audience is not allowed", "audience", nil)
+var invalidScope = gohttplib.NewServerError(400, "INVALID_SCOPE", "Scope is not allowed", "scope", nil)
+var scopedTokenForbidden = gohttplib.NewServerError(403, "SCOPED_TOKEN_FORBIDDEN", "Scoped token can't be used here", "token", nil)
+var scopeRequired = gohttplib.NewServerError(403, "SCOPE_REQUIRED", "Scope is required", "scope", nil)
+var csrfTokenMismatch = gohttplib.NewServerError(403, "CSRF_TOKEN_MISMATCH", "CSRF token is missing or invalid", "csrf", nil)
+var roleRequired = gohttplib.NewServerError(403, "ROLE_REQUIRED", "Role is required", "role", nil)
+var permissionRequired = gohttplib.NewServerError(403, "PERMISSION_REQUIRED", "Permission is required", "permission", nil)
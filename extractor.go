@@ -0,0 +1,102 @@
+package goauthlib
+
+import (
+	"github.com/techpro-studio/gohttplib"
+	"net/http"
+	"strings"
+)
+
+// TokenExtractor finds the token in the request. Empty string means the request has no token for this extractor.
+type TokenExtractor func(req *http.Request) string
+
+// ExtractToken returns the token of the first extractor which finds it.
+func ExtractToken(req *http.Request, extractors ...TokenExtractor) string {
+	for _, extractor := range extractors {
+		if token := extractor(req); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// DefaultTokenExtractors are used by UserMiddlewareFactory: Authorization header with any scheme.
+// URL parameters are opt-in, see QueryExtractor.
+func DefaultTokenExtractors() []TokenExtractor {
+	return []TokenExtractor{AuthorizationExtractor()}
+}
+
+// parseAuthorization returns credentials of "<scheme> <credentials>" value. Schemes are case-insensitive,
+// no schemes accept any scheme.
+func parseAuthorization(value string, schemes ...string) string {
+	scheme, credentials, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok {
+		return ""
+	}
+	if len(schemes) > 0 && !containsFold(schemes, scheme) {
+		return ""
+	}
+	return strings.TrimSpace(credentials)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizationExtractor reads Authorization header with one of the schemes, or any scheme when none is given.
+func AuthorizationExtractor(schemes ...string) TokenExtractor {
+	return func(req *http.Request) string {
+		return parseAuthorization(req.Header.Get("Authorization"), schemes...)
+	}
+}
+
+func BearerExtractor() TokenExtractor {
+	return AuthorizationExtractor("Bearer")
+}
+
+// HeaderExtractor reads the whole value of the header, e.g. X-Auth-Token.
+func HeaderExtractor(name string) TokenExtractor {
+	return func(req *http.Request) string {
+		return strings.TrimSpace(req.Header.Get(name))
+	}
+}
+
+func CookieExtractor(name string) TokenExtractor {
+	return func(req *http.Request) string {
+		return cookieValue(req, name)
+	}
+}
+
+// QueryExtractor reads URL parameter. URLs end up in logs and history, so use it only where headers can't be set.
+func QueryExtractor(name string) TokenExtractor {
+	return func(req *http.Request) string {
+		token := gohttplib.GetParameterFromURLInRequest(req, name)
+		if token == nil {
+			return ""
+		}
+		return *token
+	}
+}
+
+// WebSocketProtocolExtractor reads the token which browsers send as a subprotocol right after the marker,
+// e.g. new WebSocket(url, ["access_token", token]). The server has to choose the marker as the subprotocol on upgrade.
+func WebSocketProtocolExtractor(marker string) TokenExtractor {
+	return func(req *http.Request) string {
+		var protocols []string
+		for _, value := range req.Header.Values("Sec-WebSocket-Protocol") {
+			for _, protocol := range strings.Split(value, ",") {
+				protocols = append(protocols, strings.TrimSpace(protocol))
+			}
+		}
+		for i := 0; i+1 < len(protocols); i++ {
+			if protocols[i] == marker {
+				return protocols[i+1]
+			}
+		}
+		return ""
+	}
+}
@@ -0,0 +1,90 @@
+package goauthlib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestTokenExtractors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/ws?access=query-token", nil)
+	req.Header.Set("Authorization", "bearer  header-token")
+	req.Header.Set("X-Auth-Token", "custom-token")
+	req.Header.Set("Sec-WebSocket-Protocol", "chat, access_token, ws-token")
+	req.AddCookie(&http.Cookie{Name: "auth_token", Value: "cookie-token"})
+
+	cases := []struct {
+		name      string
+		extractor TokenExtractor
+		expected  string
+	}{
+		{"bearer", BearerExtractor(), "header-token"},
+		{"other scheme", AuthorizationExtractor("JWT"), ""},
+		{"any scheme", AuthorizationExtractor(), "header-token"},
+		{"header", HeaderExtractor("X-Auth-Token"), "custom-token"},
+		{"cookie", CookieExtractor("auth_token"), "cookie-token"},
+		{"query", QueryExtractor("access"), "query-token"},
+		{"websocket", WebSocketProtocolExtractor("access_token"), "ws-token"},
+		{"websocket without marker", WebSocketProtocolExtractor("token"), ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if token := tc.extractor(req); token != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, token)
+			}
+		})
+	}
+
+	if token := ExtractToken(req, AuthorizationExtractor("JWT"), CookieExtractor("auth_token")); token != "cookie-token" {
+		t.Errorf("expected first found token, got %q", token)
+	}
+}
+
+func TestUserMiddlewareWithExtractors(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	user := User{ID: bson.NewObjectID().Hex()}
+	token, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	handler := UserMiddlewareFactoryWithExtractors(*jwtCfg, WebSocketProtocolExtractor("access_token"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws?token="+token, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected query token to be ignored, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Sec-WebSocket-Protocol", "access_token, "+token)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected websocket token to be accepted, got %d", rec.Code)
+	}
+}
+
+func TestDefaultExtractorsIgnoreQuery(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	token, err := jwtCfg.GenerateTokenFromModel(User{ID: bson.NewObjectID().Hex()})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/user?token="+token, nil)
+	rec := httptest.NewRecorder()
+	UserMiddlewareFactory(*jwtCfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected query token to be ignored by default, got %d", rec.Code)
+	}
+	if GetTokenFromRequest(req) != token {
+		t.Error("expected GetTokenFromRequest to keep reading the token parameter")
+	}
+}
@@ -0,0 +1,4 @@
+// Package authpb contains protobuf messages and the gRPC service of goauthlib, see auth.proto.
+package authpb
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative grpc/authpb/auth.proto
@@ -0,0 +1,175 @@
+package grpc
+
+import (
+	"context"
+	"github.com/techpro-studio/goauthlib"
+	"github.com/techpro-studio/gohttplib"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+const authorizationMetadataKey = "authorization"
+
+// Authenticator validates tokens of incoming calls the same way as goauthlib.UserMiddlewareFactory.
+// The user is available through goauthlib.UserFromContext.
+type Authenticator struct {
+	config        goauthlib.JWTConfig
+	publicMethods map[string]bool
+	repository    goauthlib.Repository
+	cache         goauthlib.UserCache
+}
+
+// NewAuthenticator creates authenticator. Public methods, e.g. "/auth.Auth/SendCode", are called without a token,
+// but they get the user when the call has a valid one.
+func NewAuthenticator(config goauthlib.JWTConfig, publicMethods ...string) *Authenticator {
+	public := map[string]bool{}
+	for _, method := range publicMethods {
+		public[method] = true
+	}
+	return &Authenticator{config: config, publicMethods: public}
+}
+
+// SetUserLoader replaces the user from claims with the current one from repository,
+// like goauthlib.UserLoaderMiddlewareFactory does. It is meant for slim claims. Cache is optional and may be nil.
+func (a *Authenticator) SetUserLoader(repository goauthlib.Repository, cache goauthlib.UserCache) {
+	a.repository = repository
+	a.cache = cache
+}
+
+// TokenFromIncomingContext reads authorization metadata with any scheme, e.g. "Bearer <token>".
+func TokenFromIncomingContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(authorizationMetadataKey)
+	if len(values) == 0 {
+		return ""
+	}
+	_, token, ok := strings.Cut(strings.TrimSpace(values[0]), " ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func (a *Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	public := a.publicMethods[method]
+	token := TokenFromIncomingContext(ctx)
+	if token == "" {
+		if public {
+			return ctx, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "token is missing")
+	}
+	info, err := a.config.ValidateToken(ctx, token)
+	// DPoP proofs are bound to HTTP method and url, so sender-constrained tokens can't be used here.
+	if err == nil && info.KeyThumbprint != "" {
+		err = status.Error(codes.Unauthenticated, "sender-constrained token")
+	}
+	// Scoped tokens of token exchange are meant for other services, like goauthlib.UserMiddlewareFactory rejects them.
+	if err == nil && info.IsScoped() {
+		err = status.Error(codes.Unauthenticated, "scoped token")
+	}
+	if err != nil {
+		if public {
+			return ctx, nil
+		}
+		// Revoked sessions and tokens keep their codes, errors of parsing are not exposed.
+		if _, ok := err.(gohttplib.ServerError); ok {
+			return nil, StatusFromError(err)
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	if a.repository == nil {
+		return goauthlib.WithTokenInfo(ctx, info), nil
+	}
+	user := goauthlib.LoadUser(ctx, a.repository, a.cache, info.User.ID)
+	if user == nil {
+		if public {
+			return ctx, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "user not found")
+	}
+	user.Actor = info.User.Actor
+	return context.WithValue(goauthlib.WithTokenInfo(ctx, info), goauthlib.CurrentUserContextKey, user), nil
+}
+
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// TokenSource returns the token attached to outgoing calls. Empty token means the call is sent without it.
+type TokenSource func(ctx context.Context) (string, error)
+
+func StaticToken(token string) TokenSource {
+	return func(ctx context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// ForwardedToken passes the token of the incoming call. Prefer token exchange for calls to other services.
+func ForwardedToken() TokenSource {
+	return func(ctx context.Context) (string, error) {
+		return TokenFromIncomingContext(ctx), nil
+	}
+}
+
+func withToken(ctx context.Context, source TokenSource) (context.Context, error) {
+	token, err := source(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return ctx, nil
+	}
+	return metadata.AppendToOutgoingContext(ctx, authorizationMetadataKey, "Bearer "+token), nil
+}
+
+func UnaryClientInterceptor(source TokenSource) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := withToken(ctx, source)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func StreamClientInterceptor(source TokenSource) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := withToken(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
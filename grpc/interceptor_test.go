@@ -0,0 +1,167 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/techpro-studio/goauthlib"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	jwtCfg := goauthlib.NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	user := goauthlib.User{ID: bson.NewObjectID().Hex()}
+	token, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	scopedToken, err := jwtCfg.GenerateToken(context.Background(), user, goauthlib.TokenOptions{Scope: []string{"billing:read"}, ClientID: "orders"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	interceptor := NewAuthenticator(*jwtCfg, "/test.Test/Public").UnaryServerInterceptor()
+
+	var seen *goauthlib.User
+	handler := func(ctx context.Context, req any) (any, error) {
+		seen = goauthlib.UserFromContext(ctx)
+		return nil, nil
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		code          codes.Code
+		authenticated bool
+	}{
+		{"valid token", "/test.Test/Private", "Bearer " + token, codes.OK, true},
+		{"missing token", "/test.Test/Private", "", codes.Unauthenticated, false},
+		{"invalid token", "/test.Test/Private", "Bearer invalid", codes.Unauthenticated, false},
+		{"scoped token", "/test.Test/Private", "Bearer " + scopedToken, codes.Unauthenticated, false},
+		{"public without token", "/test.Test/Public", "", codes.OK, false},
+		{"public with token", "/test.Test/Public", "Bearer " + token, codes.OK, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			md := metadata.MD{}
+			if tt.authorization != "" {
+				md.Set("authorization", tt.authorization)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.code {
+				t.Fatalf("expected %v, got %v", tt.code, err)
+			}
+			if tt.authenticated && (seen == nil || seen.ID != user.ID) {
+				t.Errorf("expected user %s in context, got %v", user.ID, seen)
+			}
+			if !tt.authenticated && seen != nil {
+				t.Errorf("expected no user in context, got %v", seen)
+			}
+		})
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	jwtCfg := goauthlib.NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	user := goauthlib.User{ID: bson.NewObjectID().Hex()}
+	token, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	interceptor := NewAuthenticator(*jwtCfg).StreamServerInterceptor()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+
+	var seen *goauthlib.User
+	err = interceptor(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/test.Test/Stream"}, func(srv any, stream grpc.ServerStream) error {
+		seen = goauthlib.UserFromContext(stream.Context())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen == nil || seen.ID != user.ID {
+		t.Errorf("expected user %s in stream context, got %v", user.ID, seen)
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	interceptor := UnaryClientInterceptor(StaticToken("abc"))
+	var authorization []string
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		authorization = md.Get("authorization")
+		return nil
+	}
+	if err := interceptor(context.Background(), "/test.Test/Private", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(authorization) != 1 || authorization[0] != "Bearer abc" {
+		t.Errorf("expected bearer token in metadata, got %v", authorization)
+	}
+}
+
+type testUserRepository struct {
+	goauthlib.Repository
+	users map[string]*goauthlib.User
+}
+
+func (r *testUserRepository) GetById(ctx context.Context, id string) *goauthlib.User {
+	user, ok := r.users[id]
+	if !ok {
+		return nil
+	}
+	copied := *user
+	return &copied
+}
+
+func TestAuthenticatorUserLoader(t *testing.T) {
+	jwtCfg := goauthlib.NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetSlimClaims()
+	user := &goauthlib.User{ID: bson.NewObjectID().Hex(), Entities: []goauthlib.AuthorizationEntity{{Type: goauthlib.EntityTypeEmail, Value: "loader@test.com"}}}
+	repository := &testUserRepository{users: map[string]*goauthlib.User{user.ID: user}}
+	token, err := jwtCfg.GenerateTokenFromModel(*user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	missing, err := jwtCfg.GenerateTokenFromModel(goauthlib.User{ID: bson.NewObjectID().Hex()})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	authenticator := NewAuthenticator(*jwtCfg)
+	authenticator.SetUserLoader(repository, nil)
+	interceptor := authenticator.UnaryServerInterceptor()
+
+	var seen *goauthlib.User
+	handler := func(ctx context.Context, req any) (any, error) {
+		seen = goauthlib.UserFromContext(ctx)
+		return nil, nil
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Test/Private"}, handler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen == nil || len(seen.Entities) != 1 {
+		t.Errorf("expected the whole user from repository, got %+v", seen)
+	}
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+missing))
+	if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Test/Private"}, handler); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated for a deleted user, got %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+package grpc
+
+import (
+	"context"
+	"github.com/techpro-studio/goauthlib"
+	"github.com/techpro-studio/goauthlib/grpc/authpb"
+	"github.com/techpro-studio/gohttplib"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// PublicMethods are methods of Server which don't require a token. Pass them to NewAuthenticator.
+var PublicMethods = []string{
+	authpb.Auth_SendCode_FullMethodName,
+	authpb.Auth_AuthenticateWithCode_FullMethodName,
+	authpb.Auth_AuthenticateViaSocialProvider_FullMethodName,
+	authpb.Auth_Refresh_FullMethodName,
+	authpb.Auth_SendMagicLink_FullMethodName,
+	authpb.Auth_AuthenticateWithMagicLink_FullMethodName,
+}
+
+// Server is gRPC counterpart of goauthlib.Transport. Requests are validated the same way as HTTP bodies,
+// errors are converted with StatusFromError.
+// It must be served with interceptors of Authenticator, see PublicMethods.
+type Server struct {
+	authpb.UnimplementedAuthServer
+	useCase goauthlib.UseCase
+	proxies *goauthlib.TrustedProxies
+}
+
+func NewServer(useCase goauthlib.UseCase) *Server {
+	return &Server{useCase: useCase}
+}
+
+// SetTrustedProxies enables x-forwarded-for metadata of calls coming from the proxies, see goauthlib.TrustedProxies.
+// Without it client IP is the peer address.
+func (s *Server) SetTrustedProxies(proxies *goauthlib.TrustedProxies) {
+	s.proxies = proxies
+}
+
+func (s *Server) SendCode(ctx context.Context, req *authpb.SendCodeRequest) (*emptypb.Empty, error) {
+	entity, err := entityFromProto(req.GetEntity())
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return empty(s.useCase.SendCode(s.loginContext(ctx), *entity))
+}
+
+func (s *Server) AuthenticateWithCode(ctx context.Context, req *authpb.AuthenticateWithCodeRequest) (*authpb.AuthResponse, error) {
+	entity, code, err := s.entityAndCodeFromProto(req.GetEntity(), req.GetCode())
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return authResponse(s.useCase.AuthenticateWithCode(s.loginContext(ctx), *entity, code))
+}
+
+func (s *Server) AuthenticateViaSocialProvider(ctx context.Context, req *authpb.SocialProviderPayload) (*authpb.AuthResponse, error) {
+	payload, err := socialProviderPayloadFromProto(req)
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return authResponse(s.useCase.AuthenticateViaSocialProvider(s.loginContext(ctx), *payload))
+}
+
+func (s *Server) Refresh(ctx context.Context, req *authpb.RefreshRequest) (*authpb.AuthResponse, error) {
+	refreshToken, err := goauthlib.GetRefreshToken(map[string]interface{}{"refresh_token": req.GetRefreshToken()})
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return authResponse(s.useCase.Refresh(s.loginContext(ctx), refreshToken))
+}
+
+func (s *Server) SendMagicLink(ctx context.Context, req *authpb.SendMagicLinkRequest) (*authpb.SendMagicLinkResponse, error) {
+	entity, err := entityFromProto(req.GetEntity())
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	challenge, err := s.useCase.SendMagicLink(s.loginContext(ctx), *entity)
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	response := &authpb.SendMagicLinkResponse{}
+	if challenge != nil {
+		response.DeviceToken = challenge.DeviceToken
+		response.ConfirmationCode = challenge.ConfirmationCode
+	}
+	return response, nil
+}
+
+func (s *Server) AuthenticateWithMagicLink(ctx context.Context, req *authpb.AuthenticateWithMagicLinkRequest) (*authpb.AuthResponse, error) {
+	request, err := goauthlib.GetMagicLinkRequest(map[string]interface{}{"token": req.GetToken(), "device_token": req.GetDeviceToken(), "confirmation_code": req.GetConfirmationCode()})
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return authResponse(s.useCase.AuthenticateWithMagicLink(s.loginContext(ctx), *request))
+}
+
+func (s *Server) GetCurrentUser(ctx context.Context, _ *emptypb.Empty) (*authpb.User, error) {
+	usr, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return userResponse(usr, nil)
+}
+
+func (s *Server) SendEntityCode(ctx context.Context, req *authpb.SendEntityCodeRequest) (*emptypb.Empty, error) {
+	usr, err := currentUser(ctx, goauthlib.DenyReadOnlyImpersonation)
+	if err != nil {
+		return nil, err
+	}
+	entity, err := entityFromProto(req.GetEntity())
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return empty(s.useCase.SendCodeWithUser(s.loginContext(ctx), *usr, *entity))
+}
+
+func (s *Server) VerifyEntity(ctx context.Context, req *authpb.VerifyEntityRequest) (*authpb.User, error) {
+	usr, err := currentUser(ctx, goauthlib.DenyReadOnlyImpersonation)
+	if err != nil {
+		return nil, err
+	}
+	entity, code, err := s.entityAndCodeFromProto(req.GetEntity(), req.GetCode())
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return userResponse(s.useCase.VerifyAuthenticationEntity(ctx, usr, *entity, code))
+}
+
+func (s *Server) AddSocialEntity(ctx context.Context, req *authpb.SocialProviderPayload) (*authpb.User, error) {
+	usr, err := currentUser(ctx, goauthlib.DenyReadOnlyImpersonation)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := socialProviderPayloadFromProto(req)
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return userResponse(s.useCase.AddSocialAuthenticationEntity(ctx, usr, *payload))
+}
+
+func (s *Server) RemoveEntity(ctx context.Context, req *authpb.RemoveEntityRequest) (*emptypb.Empty, error) {
+	usr, err := currentUser(ctx, goauthlib.DenyImpersonation)
+	if err != nil {
+		return nil, err
+	}
+	entity, err := entityFromProto(req.GetEntity())
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return empty(s.useCase.RemoveAuthenticationEntity(ctx, *usr, *entity))
+}
+
+func (s *Server) PatchUserInfo(ctx context.Context, req *authpb.PatchUserInfoRequest) (*authpb.User, error) {
+	usr, err := currentUser(ctx, goauthlib.DenyReadOnlyImpersonation)
+	if err != nil {
+		return nil, err
+	}
+	return userResponse(s.useCase.PatchUserInfo(ctx, usr, req.GetInfo().AsMap()))
+}
+
+func (s *Server) SendDeleteCode(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	usr, err := currentUser(ctx, goauthlib.DenyImpersonation)
+	if err != nil {
+		return nil, err
+	}
+	return empty(s.useCase.SendVerificationCode(ctx, *usr, goauthlib.DeleteAccountAction))
+}
+
+func (s *Server) VerifyDelete(ctx context.Context, req *authpb.VerifyDeleteRequest) (*emptypb.Empty, error) {
+	usr, err := currentUser(ctx, goauthlib.DenyImpersonation)
+	if err != nil {
+		return nil, err
+	}
+	code, err := goauthlib.GetCodeForSpec(map[string]interface{}{"code": req.GetCode()}, s.useCase.CodeSpec(goauthlib.EntityTypeEmail))
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return empty(s.useCase.VerifyDelete(ctx, *usr, code))
+}
+
+func (s *Server) ForceDelete(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	usr, err := currentUser(ctx, goauthlib.DenyImpersonation)
+	if err != nil {
+		return nil, err
+	}
+	return empty(s.useCase.ForceDelete(ctx, *usr))
+}
+
+// currentUser returns the user put by Authenticator after checks of the method, e.g. goauthlib.DenyImpersonation.
+func currentUser(ctx context.Context, checks ...func(ctx context.Context) error) (*goauthlib.User, error) {
+	usr := goauthlib.UserFromContext(ctx)
+	if usr == nil {
+		return nil, StatusFromError(gohttplib.HTTP401())
+	}
+	for _, check := range checks {
+		if err := check(ctx); err != nil {
+			return nil, StatusFromError(err)
+		}
+	}
+	copied := *usr
+	return &copied, nil
+}
+
+// loginContext carries client info for sessions and send quotas, like goauthlib.ClientInfoFromRequest does for HTTP,
+// and the token of the call for step-up.
+func (s *Server) loginContext(ctx context.Context) context.Context {
+	info := goauthlib.ClientInfo{}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.IP = s.proxies.ClientIP(p.Addr.String(), md.Get("x-forwarded-for")...)
+	}
+	if userAgent := md.Get("user-agent"); len(userAgent) > 0 {
+		info.UserAgent = userAgent[0]
+	}
+	if device := md.Get("x-device"); len(device) > 0 {
+		info.Device = device[0]
+	}
+	return goauthlib.WithStepUpToken(goauthlib.WithClientInfo(ctx, info), TokenFromIncomingContext(ctx))
+}
+
+func empty(err error) (*emptypb.Empty, error) {
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func entityFromProto(entity *authpb.AuthorizationEntity) (*goauthlib.AuthorizationEntity, error) {
+	return goauthlib.GetAuthorizationEntityFromBody(map[string]interface{}{"value": entity.GetValue()})
+}
+
+func (s *Server) entityAndCodeFromProto(entity *authpb.AuthorizationEntity, code string) (*goauthlib.AuthorizationEntity, string, error) {
+	validated, err := entityFromProto(entity)
+	if err != nil {
+		return nil, "", err
+	}
+	code, err = goauthlib.GetCodeForSpec(map[string]interface{}{"code": code}, s.useCase.CodeSpec(validated.Type))
+	if err != nil {
+		return nil, "", err
+	}
+	return validated, code, nil
+}
+
+func socialProviderPayloadFromProto(req *authpb.SocialProviderPayload) (*goauthlib.SocialProviderPayload, error) {
+	body := req.GetRemaining().AsMap()
+	body["provider"] = req.GetProvider()
+	body["payload"] = req.GetPayload()
+	body["payload_type"] = req.GetPayloadType()
+	return goauthlib.GetSocialProviderPayloadInfo(body)
+}
+
+func authResponse(response *goauthlib.Response, err error) (*authpb.AuthResponse, error) {
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	usr, err := userToProto(response.User)
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	result := &authpb.AuthResponse{Token: response.Token, RefreshToken: response.RefreshToken, User: usr}
+	if len(response.UserInfo) > 0 {
+		result.UserInfo, err = structpb.NewStruct(response.UserInfo)
+		if err != nil {
+			return nil, StatusFromError(err)
+		}
+	}
+	return result, nil
+}
+
+func userResponse(usr *goauthlib.User, err error) (*authpb.User, error) {
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	result, err := userToProto(*usr)
+	if err != nil {
+		return nil, StatusFromError(err)
+	}
+	return result, nil
+}
+
+func userToProto(usr goauthlib.User) (*authpb.User, error) {
+	result := &authpb.User{Id: usr.ID, Roles: usr.Roles, Permissions: usr.Permissions}
+	for _, entity := range usr.Entities {
+		result.Entities = append(result.Entities, &authpb.AuthorizationEntity{Type: entity.Type, Value: entity.Value})
+	}
+	if len(usr.Info) > 0 {
+		info, err := structpb.NewStruct(usr.Info)
+		if err != nil {
+			return nil, err
+		}
+		result.Info = info
+	}
+	return result, nil
+}
@@ -0,0 +1,188 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/techpro-studio/goauthlib"
+	"github.com/techpro-studio/goauthlib/grpc/authpb"
+	"github.com/techpro-studio/gohttplib"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type testUseCase struct {
+	goauthlib.UseCase
+	user goauthlib.User
+}
+
+func (u *testUseCase) AuthenticateWithCode(ctx context.Context, entity goauthlib.AuthorizationEntity, code string) (*goauthlib.Response, error) {
+	if code != "123456" {
+		return nil, gohttplib.NewServerError(403, "INVALID_CODE", "Invalid code", "codee", nil)
+	}
+	return &goauthlib.Response{Token: "token", User: u.user}, nil
+}
+
+func (u *testUseCase) CodeSpec(entityType string) goauthlib.CodeSpec {
+	return goauthlib.DefaultCodeSpec()
+}
+
+func (u *testUseCase) PatchUserInfo(ctx context.Context, usr *goauthlib.User, body map[string]interface{}) (*goauthlib.User, error) {
+	usr.Info = body
+	return usr, nil
+}
+
+func (u *testUseCase) ForceDelete(ctx context.Context, usr goauthlib.User) error {
+	return nil
+}
+
+func newTestClient(t *testing.T, useCase goauthlib.UseCase, config goauthlib.JWTConfig) authpb.AuthClient {
+	listener := bufconn.Listen(1024 * 1024)
+	authenticator := NewAuthenticator(config, PublicMethods...)
+	server := grpc.NewServer(grpc.UnaryInterceptor(authenticator.UnaryServerInterceptor()))
+	authpb.RegisterAuthServer(server, NewServer(useCase))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return authpb.NewAuthClient(conn)
+}
+
+func TestServerTranslatesErrors(t *testing.T) {
+	jwtCfg := goauthlib.NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	user := goauthlib.User{ID: bson.NewObjectID().Hex()}
+	client := newTestClient(t, &testUseCase{user: user}, *jwtCfg)
+	ctx := context.Background()
+	entity := &authpb.AuthorizationEntity{Value: "user@example.com"}
+
+	response, err := client.AuthenticateWithCode(ctx, &authpb.AuthenticateWithCodeRequest{Entity: entity, Code: "123456"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.GetToken() != "token" || response.GetUser().GetId() != user.ID {
+		t.Errorf("unexpected response %v", response)
+	}
+
+	tests := []struct {
+		name   string
+		call   func() error
+		code   codes.Code
+		reason string
+	}{
+		{"use case error", func() error {
+			_, err := client.AuthenticateWithCode(ctx, &authpb.AuthenticateWithCodeRequest{Entity: entity, Code: "000000"})
+			return err
+		}, codes.PermissionDenied, "INVALID_CODE"},
+		{"validation error", func() error {
+			_, err := client.SendCode(ctx, &authpb.SendCodeRequest{Entity: &authpb.AuthorizationEntity{Value: "invalid"}})
+			return err
+		}, codes.InvalidArgument, "INVALID_CREDENTIAL"},
+		{"missing token", func() error {
+			_, err := client.GetCurrentUser(ctx, &emptypb.Empty{})
+			return err
+		}, codes.Unauthenticated, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if status.Code(err) != tt.code {
+				t.Fatalf("expected %v, got %v", tt.code, err)
+			}
+			serverError, ok := ServerErrorFromStatus(err)
+			if tt.reason == "" {
+				if ok {
+					t.Errorf("expected no details, got %v", serverError)
+				}
+				return
+			}
+			if !ok || serverError.Code != tt.reason {
+				t.Errorf("expected %s, got %v", tt.reason, err)
+			}
+		})
+	}
+}
+
+func TestServerAuthenticatedMethods(t *testing.T) {
+	jwtCfg := goauthlib.NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	user := goauthlib.User{ID: bson.NewObjectID().Hex()}
+	token, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	client := newTestClient(t, &testUseCase{user: user}, *jwtCfg)
+	ctx, err := withToken(context.Background(), StaticToken(token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	current, err := client.GetCurrentUser(ctx, &emptypb.Empty{})
+	if err != nil || current.GetId() != user.ID {
+		t.Fatalf("expected user %s, got %v, %v", user.ID, current, err)
+	}
+
+	info, _ := structpb.NewStruct(map[string]interface{}{"name": "John"})
+	patched, err := client.PatchUserInfo(ctx, &authpb.PatchUserInfoRequest{Info: info})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if patched.GetInfo().GetFields()["name"].GetStringValue() != "John" {
+		t.Errorf("expected patched info, got %v", patched.GetInfo())
+	}
+}
+
+func TestServerDeniesImpersonation(t *testing.T) {
+	server := NewServer(&testUseCase{})
+	info := &goauthlib.TokenInfo{User: goauthlib.User{ID: "user"}, Actor: &goauthlib.Actor{ID: "admin", ReadOnly: true}}
+	ctx := goauthlib.WithTokenInfo(context.Background(), info)
+
+	_, err := server.ForceDelete(ctx, &emptypb.Empty{})
+	if serverError, ok := ServerErrorFromStatus(err); !ok || serverError.Code != "IMPERSONATION_FORBIDDEN" {
+		t.Errorf("expected IMPERSONATION_FORBIDDEN, got %v", err)
+	}
+	_, err = server.PatchUserInfo(ctx, &authpb.PatchUserInfoRequest{})
+	if serverError, ok := ServerErrorFromStatus(err); !ok || serverError.Code != "IMPERSONATION_READ_ONLY" {
+		t.Errorf("expected IMPERSONATION_READ_ONLY, got %v", err)
+	}
+}
+
+func TestServerClientIP(t *testing.T) {
+	proxies, err := goauthlib.NewTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("NewTrustedProxies failed: %v", err)
+	}
+	incoming := func(remoteAddr string) context.Context {
+		addr, _ := net.ResolveTCPAddr("tcp", remoteAddr)
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", "1.1.1.1"))
+	}
+
+	server := NewServer(&testUseCase{})
+	if ip := goauthlib.ClientInfoFromContext(server.loginContext(incoming("10.0.0.2:5000"))).IP; ip != "10.0.0.2" {
+		t.Errorf("expected peer address by default, got %s", ip)
+	}
+	server.SetTrustedProxies(proxies)
+	if ip := goauthlib.ClientInfoFromContext(server.loginContext(incoming("10.0.0.2:5000"))).IP; ip != "1.1.1.1" {
+		t.Errorf("expected forwarded address of trusted proxy, got %s", ip)
+	}
+	if ip := goauthlib.ClientInfoFromContext(server.loginContext(incoming("203.0.113.7:5000"))).IP; ip != "203.0.113.7" {
+		t.Errorf("expected forwarded address of untrusted peer to be ignored, got %s", ip)
+	}
+}
@@ -0,0 +1,87 @@
+package grpc
+
+import (
+	"errors"
+	"github.com/techpro-studio/gohttplib"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strconv"
+)
+
+// ErrorDomain is the domain of google.rpc.ErrorInfo attached to errors of Server.
+const ErrorDomain = "goauthlib"
+
+const (
+	fieldMetadataKey      = "field"
+	httpStatusMetadataKey = "http_status"
+)
+
+var httpToCode = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.AlreadyExists,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusInternalServerError: codes.Internal,
+}
+
+// StatusFromError converts gohttplib.ServerError to a status with the same meaning. Code of ServerError
+// goes to reason of google.rpc.ErrorInfo, so clients can tell e.g. INVALID_CODE from HAS_ALREADY_USER.
+func StatusFromError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var serverError gohttplib.ServerError
+	if !errors.As(err, &serverError) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	code, ok := httpToCode[serverError.StatusCode]
+	if !ok {
+		code = codes.Unknown
+	}
+	st := status.New(code, serverError.Description)
+	if serverError.Code == "" {
+		return st.Err()
+	}
+	withDetails, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: serverError.Code,
+		Domain: ErrorDomain,
+		Metadata: map[string]string{
+			fieldMetadataKey:      serverError.Field,
+			httpStatusMetadataKey: strconv.Itoa(serverError.StatusCode),
+		},
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// ServerErrorFromStatus restores gohttplib.ServerError on the client side.
+// It returns false when the error doesn't come from Server.
+func ServerErrorFromStatus(err error) (gohttplib.ServerError, bool) {
+	st, ok := status.FromError(err)
+	if !ok || err == nil {
+		return gohttplib.ServerError{}, false
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != ErrorDomain {
+			continue
+		}
+		httpStatus, convErr := strconv.Atoi(info.Metadata[httpStatusMetadataKey])
+		if convErr != nil {
+			httpStatus = http.StatusInternalServerError
+		}
+		return gohttplib.NewServerError(httpStatus, info.Reason, st.Message(), info.Metadata[fieldMetadataKey], nil), true
+	}
+	return gohttplib.ServerError{}, false
+}
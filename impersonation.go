@@ -0,0 +1,99 @@
+package goauthlib
+
+import (
+	"context"
+	"github.com/techpro-studio/gohttplib"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	ImpersonationStarted = "impersonation_started"
+	ImpersonationStopped = "impersonation_stopped"
+)
+
+const defaultImpersonationTTL = 15 * time.Minute
+
+// Actor is an admin who acts as the user, it is sent in act claim (RFC 8693).
+type Actor struct {
+	ID       string `json:"id"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+type ImpersonationRequest struct {
+	UserID   string
+	ReadOnly bool
+	Reason   string
+}
+
+type ImpersonationEvent struct {
+	Action   string
+	ActorID  string
+	UserID   string
+	TokenID  string
+	ReadOnly bool
+	Reason   string
+	Time     time.Time
+}
+
+// AuditLogger records security sensitive actions. It must not fail the action, so it returns nothing.
+type AuditLogger interface {
+	LogImpersonation(ctx context.Context, event ImpersonationEvent)
+}
+
+// LogAuditLogger writes events to the standard logger. It is used when no other logger is set.
+type LogAuditLogger struct {
+}
+
+func (l LogAuditLogger) LogImpersonation(ctx context.Context, event ImpersonationEvent) {
+	log.Printf("audit: %s actor=%s user=%s token=%s read_only=%t reason=%q", event.Action, event.ActorID, event.UserID, event.TokenID, event.ReadOnly, event.Reason)
+}
+
+func GetActorFromRequest(req *http.Request) *Actor {
+	info := GetTokenInfoFromRequest(req)
+	if info == nil {
+		return nil
+	}
+	return info.Actor
+}
+
+// DenyImpersonation returns an error when the token of the context is an impersonation token.
+func DenyImpersonation(ctx context.Context) error {
+	if info := TokenInfoFromContext(ctx); info != nil && info.Actor != nil {
+		return impersonationForbidden
+	}
+	return nil
+}
+
+// DenyReadOnlyImpersonation returns an error when the token of the context is a read only impersonation token.
+func DenyReadOnlyImpersonation(ctx context.Context) error {
+	if info := TokenInfoFromContext(ctx); info != nil && info.Actor != nil && info.Actor.ReadOnly {
+		return impersonationReadOnly
+	}
+	return nil
+}
+
+// DenyImpersonationMiddleware is used for destructive routes which only the user can call.
+// It must be used after UserMiddlewareFactory.
+func DenyImpersonationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := DenyImpersonation(req.Context()); err != nil {
+			gohttplib.SafeConvertToServerError(err).Write(w)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// DenyReadOnlyImpersonationMiddleware is used for routes which change the user.
+// It must be used after UserMiddlewareFactory.
+func DenyReadOnlyImpersonationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := DenyReadOnlyImpersonation(req.Context()); err != nil {
+			gohttplib.SafeConvertToServerError(err).Write(w)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,82 @@
+package goauthlib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type testAuditLogger struct {
+	events []ImpersonationEvent
+}
+
+func (l *testAuditLogger) LogImpersonation(ctx context.Context, event ImpersonationEvent) {
+	l.events = append(l.events, event)
+}
+
+func TestImpersonation(t *testing.T) {
+	admin := User{ID: bson.NewObjectID().Hex()}
+	target := &User{ID: bson.NewObjectID().Hex(), Info: map[string]any{}}
+	repository := &testUserRepository{users: map[string]*User{target.ID: target}}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetAccessTokenTTL(time.Hour)
+	jwtCfg.SetRevocationStore(NewMemoryRevocationStore())
+	audit := &testAuditLogger{}
+	useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+	useCase.SetAuditLogger(audit)
+
+	response, err := useCase.StartImpersonation(context.Background(), admin, ImpersonationRequest{UserID: target.ID, ReadOnly: true, Reason: "ticket"})
+	if err != nil {
+		t.Fatalf("StartImpersonation failed: %v", err)
+	}
+	if response.RefreshToken != "" {
+		t.Error("impersonation must not issue refresh token")
+	}
+	info, err := jwtCfg.ValidateToken(context.Background(), response.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if info.User.ID != target.ID || info.Actor == nil || info.Actor.ID != admin.ID || !info.Actor.ReadOnly {
+		t.Fatalf("unexpected impersonation token: user %s, actor %+v", info.User.ID, info.Actor)
+	}
+	if info.User.Actor == nil {
+		t.Error("expected actor on the user")
+	}
+	if time.Until(info.ExpiresAt) > defaultImpersonationTTL {
+		t.Errorf("expected short-lived token, expires at %v", info.ExpiresAt)
+	}
+	if _, err := useCase.StartImpersonation(context.Background(), info.User, ImpersonationRequest{UserID: admin.ID}); err == nil {
+		t.Error("expected nested impersonation to be refused")
+	}
+
+	handler := UserMiddlewareFactory(*jwtCfg)(DenyReadOnlyImpersonationMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	req := httptest.NewRequest(http.MethodPatch, "/user/info", nil)
+	req.Header.Set("Authorization", "Bearer "+response.Token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for read-only impersonation, got %d", rec.Code)
+	}
+
+	ctx := context.WithValue(context.Background(), CurrentTokenContextKey, info)
+	if err := useCase.StopImpersonation(ctx, info.User); err != nil {
+		t.Fatalf("StopImpersonation failed: %v", err)
+	}
+	if _, err := jwtCfg.ValidateToken(context.Background(), response.Token); err == nil {
+		t.Error("expected stopped impersonation token to be revoked")
+	}
+	if len(audit.events) != 2 || audit.events[0].Action != ImpersonationStarted || audit.events[1].Action != ImpersonationStopped {
+		t.Fatalf("unexpected audit events: %+v", audit.events)
+	}
+	if audit.events[0].Reason != "ticket" || audit.events[0].TokenID != info.ID {
+		t.Errorf("unexpected start event: %+v", audit.events[0])
+	}
+}
@@ -0,0 +1,76 @@
+package goauthlib
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWKS(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key pair: %v", err)
+	}
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	hsKey := []byte("secret")
+
+	keySet := NewKeySet(
+		JWTKey{ID: "ed", SigningMethod: jwt.SigningMethodEdDSA, SigningKey: edPrivate, VerificationKey: edPublic},
+		JWTKey{ID: "rsa", SigningMethod: jwt.SigningMethodRS256, SigningKey: rsaPrivate, VerificationKey: &rsaPrivate.PublicKey},
+		JWTKey{ID: "hs", SigningMethod: jwt.SigningMethodHS256, SigningKey: hsKey, VerificationKey: hsKey},
+	)
+	jwks := NewJWTConfigWithKeySet(keySet, "blinder").JWKS()
+
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 published keys, got %d", len(jwks.Keys))
+	}
+	ed := jwks.Keys[0]
+	if ed.Kid != "ed" || ed.Kty != "OKP" || ed.Crv != "Ed25519" || ed.Alg != "EdDSA" {
+		t.Errorf("unexpected Ed25519 jwk: %+v", ed)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(ed.X)
+	if err != nil || !edPublic.Equal(ed25519.PublicKey(x)) {
+		t.Errorf("Ed25519 public key mismatch")
+	}
+	rsaKey := jwks.Keys[1]
+	if rsaKey.Kid != "rsa" || rsaKey.Kty != "RSA" || rsaKey.E != "AQAB" || rsaKey.N == "" {
+		t.Errorf("unexpected RSA jwk: %+v", rsaKey)
+	}
+
+	hmacOnly := JWTConfig{
+		signingMethod:   jwt.SigningMethodHS256,
+		signingKey:      hsKey,
+		verificationKey: hsKey,
+	}
+	if len(hmacOnly.JWKS().Keys) != 0 {
+		t.Error("expected symmetric key to be never published")
+	}
+}
+
+func TestJWKSHandler(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key pair: %v", err)
+	}
+	cfg := NewJWTConfig(jwt.SigningMethodEdDSA, edPrivate, edPublic, "blinder")
+
+	recorder := httptest.NewRecorder()
+	JWKSHandlerFactory(*cfg)(recorder, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+	var set JWKSet
+	if err := json.Unmarshal(recorder.Body.Bytes(), &set); err != nil {
+		t.Fatalf("failed to decode jwks: %v", err)
+	}
+	if len(set.Keys) != 1 || set.Keys[0].Crv != "Ed25519" {
+		t.Errorf("unexpected jwks: %+v", set)
+	}
+}
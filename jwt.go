@@ -1,6 +1,7 @@
 package goauthlib
 
 import (
+	"context"
 	"crypto/sha3"
 	"encoding/hex"
 	"encoding/json"
@@ -9,38 +10,256 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type JWTConfig struct {
 	signingMethod   jwt.SigningMethod
 	signingKey      any
 	verificationKey any
+	signer          Signer
+	format          TokenFormat
 	blinder         string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	keySet          *KeySet
+	sessions        SessionRepository
+	revocations     RevocationStore
+	tokenVersions   TokenVersionSource
+	slimClaims      bool
+	slimInfoKeys    []string
+	issuer          string
+	audience        []string
+	dpop            *DPoPVerifier
+}
+
+const defaultIssuer = "auth"
+
+// TokenOptions are per-token values added to the claims next to the user.
+type TokenOptions struct {
+	SessionID string
+	// KeyThumbprint binds the token to the DPoP key, see DPoPMiddlewareFactory.
+	KeyThumbprint string
+	// AuthMethods and AuthTime describe how and when the user logged in. acr is derived from the methods.
+	AuthMethods []string
+	AuthTime    time.Time
+	// Actor makes an impersonation token.
+	Actor *Actor
+	// TTL overrides AccessTokenTTL of the config, e.g. for short-lived impersonation tokens.
+	TTL time.Duration
+	// Audience overrides audience of the config for tokens minted for another service.
+	Audience []string
+	// Scope limits what the token can be used for. Empty scope means the token is not limited.
+	Scope []string
+	// ClientID is the service client which got the token by token exchange.
+	ClientID string
+}
+
+// TokenInfo is a validated token.
+type TokenInfo struct {
+	ID        string
+	User      User
+	SessionID string
+	// KeyThumbprint is set for tokens bound to the DPoP key.
+	KeyThumbprint string
+	AuthMethods   []string
+	AuthLevel     int
+	AuthTime      time.Time
+	Actor         *Actor
+	Scope         []string
+	ClientID      string
+	ExpiresAt     time.Time
+	Claims        map[string]any
+}
+
+// IsScoped reports whether the token is limited by scope or minted for a service client by token exchange.
+// Such tokens are accepted only by ScopedUserMiddlewareFactory.
+func (info TokenInfo) IsScoped() bool {
+	return info.ClientID != "" || len(info.Scope) > 0
+}
+
+// HasScope reports whether the token allows all the scopes. Tokens without scope are not limited.
+func (info TokenInfo) HasScope(scopes ...string) bool {
+	if len(info.Scope) == 0 {
+		return true
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(info.Scope, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+type confirmationClaim struct {
+	JKT string `json:"jkt"`
+}
+
+type actorClaim struct {
+	Subject string `json:"sub"`
+}
+
+type tokenClaims struct {
+	Hash         string             `json:"hash"`
+	User         *User              `json:"user,omitempty"`
+	Info         map[string]any     `json:"info,omitempty"`
+	Roles        []string           `json:"roles,omitempty"`
+	Permissions  []string           `json:"permissions,omitempty"`
+	SessionID    string             `json:"sid,omitempty"`
+	TokenVersion int64              `json:"ver,omitempty"`
+	Confirmation *confirmationClaim `json:"cnf,omitempty"`
+	AuthMethods  []string           `json:"amr,omitempty"`
+	AuthLevel    string             `json:"acr,omitempty"`
+	AuthTime     int64              `json:"auth_time,omitempty"`
+	Actor        *actorClaim        `json:"act,omitempty"`
+	ReadOnly     bool               `json:"read_only,omitempty"`
+	Scope        string             `json:"scope,omitempty"`
+	ClientID     string             `json:"client_id,omitempty"`
+	jwt.RegisteredClaims
 }
 
 func (config JWTConfig) SigningMethod() jwt.SigningMethod {
-	return config.signingMethod
+	return config.activeKey().SigningMethod
 }
 
 func (config JWTConfig) SigningKey() any {
-	return config.signingKey
+	return config.activeKey().SigningKey
 }
 
 func (config JWTConfig) VerificationKey() any {
-	return config.verificationKey
+	return config.activeKey().verificationKey()
+}
+
+// SessionRepository is nil when sessions are not tracked.
+func (config JWTConfig) SessionRepository() SessionRepository {
+	return config.sessions
+}
+
+// SetSessionRepository enables sessions. Tokens without a session or with revoked one are rejected by ValidateToken.
+func (config *JWTConfig) SetSessionRepository(sessions SessionRepository) {
+	config.sessions = sessions
+}
+
+// RevocationStore is nil when individual tokens can't be revoked.
+func (config JWTConfig) RevocationStore() RevocationStore {
+	return config.revocations
+}
+
+func (config *JWTConfig) SetRevocationStore(revocations RevocationStore) {
+	config.revocations = revocations
+}
+
+// TokenVersionSource is nil when token version of the user is not checked.
+func (config JWTConfig) TokenVersionSource() TokenVersionSource {
+	return config.tokenVersions
+}
+
+// SetTokenVersionSource enables rejection of tokens issued before the last token version bump of the user.
+func (config *JWTConfig) SetTokenVersionSource(tokenVersions TokenVersionSource) {
+	config.tokenVersions = tokenVersions
+}
+
+// SlimClaims reports whether tokens carry only sub, sid and chosen info keys instead of the whole user.
+func (config JWTConfig) SlimClaims() bool {
+	return config.slimClaims
+}
+
+// SetSlimClaims switches tokens to slim claims. Only listed keys of User.Info are put into the token.
+// Users parsed from slim tokens have only ID and those keys, use UserLoaderMiddlewareFactory to get the whole user.
+// DefaultUseCase reloads the user itself before changing it.
+func (config *JWTConfig) SetSlimClaims(infoKeys ...string) {
+	config.slimClaims = true
+	config.slimInfoKeys = infoKeys
+}
+
+func (config JWTConfig) Issuer() string {
+	if config.issuer == "" {
+		return defaultIssuer
+	}
+	return config.issuer
+}
+
+// SetIssuer changes iss claim of issued tokens. Tokens of another issuer are rejected.
+func (config *JWTConfig) SetIssuer(issuer string) {
+	config.issuer = issuer
+}
+
+func (config JWTConfig) Audience() []string {
+	return config.audience
+}
+
+// SetAudience sets aud claim of issued tokens, usually to the service name.
+// Tokens which are not minted for any of these audiences are rejected, so token of one service
+// can't be replayed against another service which shares the same key.
+func (config *JWTConfig) SetAudience(audience ...string) {
+	config.audience = audience
+}
+
+// DPoPVerifier is nil when tokens can't be bound to the client key.
+func (config JWTConfig) DPoPVerifier() *DPoPVerifier {
+	return config.dpop
+}
+
+// SetDPoPVerifier enables sender-constrained tokens. Tokens with cnf claim are accepted by UserMiddlewareFactory
+// only with a valid DPoP proof of the bound key.
+func (config *JWTConfig) SetDPoPVerifier(verifier *DPoPVerifier) {
+	config.dpop = verifier
+}
+
+// Format is nil for configs which issue JWT.
+func (config JWTConfig) Format() TokenFormat {
+	return config.format
+}
+
+// KeySet is nil for configs with a single key pair.
+func (config JWTConfig) KeySet() *KeySet {
+	return config.keySet
 }
 
 func (config JWTConfig) Blinder() string {
 	return config.blinder
 }
 
+// AccessTokenTTL is a lifetime of issued access tokens. Zero means tokens never expire.
+func (config JWTConfig) AccessTokenTTL() time.Duration {
+	return config.accessTokenTTL
+}
+
+// RefreshTokenTTL is a lifetime of issued refresh tokens. Zero means refresh tokens are not issued.
+func (config JWTConfig) RefreshTokenTTL() time.Duration {
+	return config.refreshTokenTTL
+}
+
+func (config *JWTConfig) SetAccessTokenTTL(ttl time.Duration) {
+	config.accessTokenTTL = ttl
+}
+
+func (config *JWTConfig) SetRefreshTokenTTL(ttl time.Duration) {
+	config.refreshTokenTTL = ttl
+}
+
 func (config JWTConfig) Copy() JWTConfig {
 	return JWTConfig{
 		signingMethod:   config.signingMethod,
 		signingKey:      config.signingKey,
 		verificationKey: config.verificationKey,
+		signer:          config.signer,
+		format:          config.format,
 		blinder:         config.blinder,
+		accessTokenTTL:  config.accessTokenTTL,
+		refreshTokenTTL: config.refreshTokenTTL,
+		keySet:          config.keySet,
+		sessions:        config.sessions,
+		revocations:     config.revocations,
+		tokenVersions:   config.tokenVersions,
+		slimClaims:      config.slimClaims,
+		slimInfoKeys:    config.slimInfoKeys,
+		issuer:          config.issuer,
+		audience:        config.audience,
+		dpop:            config.dpop,
 	}
 }
 
@@ -48,35 +267,134 @@ func NewJWTConfig(signingMethod jwt.SigningMethod, signingKey any, verificationK
 	return &JWTConfig{signingMethod: signingMethod, signingKey: signingKey, verificationKey: verificationKey, blinder: blinder}
 }
 
+// NewJWTConfigWithSigner creates config which signs through signer and verifies with its public key.
+func NewJWTConfigWithSigner(signingMethod jwt.SigningMethod, signer Signer, blinder string) *JWTConfig {
+	return &JWTConfig{signingMethod: signingMethod, signer: signer, blinder: blinder}
+}
+
+// NewJWTConfigWithKeySet creates config which signs with the active key of the set
+// and verifies tokens with the key referenced by their kid header.
+func NewJWTConfigWithKeySet(keySet *KeySet, blinder string) *JWTConfig {
+	return &JWTConfig{keySet: keySet, blinder: blinder}
+}
+
+func (config JWTConfig) activeKey() JWTKey {
+	if config.keySet != nil {
+		return config.keySet.Active()
+	}
+	return JWTKey{
+		SigningMethod:   config.signingMethod,
+		SigningKey:      config.signingKey,
+		VerificationKey: config.verificationKey,
+		Signer:          config.signer,
+	}
+}
+
+func (config JWTConfig) verificationKeyFor(token *jwt.Token) (any, error) {
+	key := config.activeKey()
+	if config.keySet != nil {
+		kid, _ := token.Header["kid"].(string)
+		found, ok := config.keySet.Get(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %v", token.Header["kid"])
+		}
+		key = found
+	}
+	if token.Method.Alg() != key.SigningMethod.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verificationKey(), nil
+}
+
 func (config JWTConfig) GenerateTokenFromModel(model User) (string, error) {
+	return config.GenerateToken(context.Background(), model, TokenOptions{})
+}
+
+// GenerateToken issues the token for the user. Context is passed to Signer.
+func (config JWTConfig) GenerateToken(ctx context.Context, model User, options TokenOptions) (string, error) {
 	hash := GenerateTokenHash(model, config.blinder)
+	tokenId, err := newTokenId()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	registeredClaims := jwt.RegisteredClaims{
+		ID:        tokenId,
+		Subject:   model.ID,
+		Issuer:    config.Issuer(),
+		Audience:  config.audience,
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+	ttl := config.accessTokenTTL
+	if options.TTL > 0 {
+		ttl = options.TTL
+	}
+	if len(options.Audience) > 0 {
+		registeredClaims.Audience = options.Audience
+	}
+	if ttl > 0 {
+		registeredClaims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	}
 
-	claims := struct {
-		Hash string `json:"hash"`
-		User User   `json:"user"`
-		jwt.RegisteredClaims
-	}{hash,
-		model,
-		jwt.RegisteredClaims{
-			Issuer: "auth",
-		},
+	claims := tokenClaims{
+		Hash:             hash,
+		SessionID:        options.SessionID,
+		TokenVersion:     model.TokenVersion,
+		Scope:            strings.Join(options.Scope, " "),
+		ClientID:         options.ClientID,
+		RegisteredClaims: registeredClaims,
+	}
+	if len(options.AuthMethods) > 0 {
+		claims.AuthMethods = options.AuthMethods
+		claims.AuthLevel = strconv.Itoa(authLevel(options.AuthMethods))
+	}
+	if !options.AuthTime.IsZero() {
+		claims.AuthTime = options.AuthTime.Unix()
+	}
+	if options.Actor != nil {
+		claims.Actor = &actorClaim{Subject: options.Actor.ID}
+		claims.ReadOnly = options.Actor.ReadOnly
+	}
+	if options.KeyThumbprint != "" {
+		claims.Confirmation = &confirmationClaim{JKT: options.KeyThumbprint}
+	}
+	if config.slimClaims {
+		claims.Info = config.slimInfo(model)
+		claims.Roles = model.Roles
+		claims.Permissions = model.Permissions
+	} else {
+		claims.User = &model
+	}
+	if config.format != nil {
+		return config.format.Sign(ctx, claims)
+	}
+	key := config.activeKey()
+	tokenObj := jwt.NewWithClaims(key.SigningMethod, claims)
+	if key.ID != "" {
+		tokenObj.Header["kid"] = key.ID
 	}
-	tokenObj := jwt.NewWithClaims(config.signingMethod, claims)
 
-	token, err := tokenObj.SignedString(config.signingKey)
+	token, err := signToken(ctx, tokenObj, key)
 	if err != nil {
 		return "", err
 	}
 	return token, nil
 }
 
+func (config JWTConfig) parserOptions() []jwt.ParserOption {
+	options := []jwt.ParserOption{jwt.WithIssuer(config.Issuer())}
+	if len(config.audience) > 0 {
+		options = append(options, jwt.WithAudience(config.audience...))
+	}
+	return options
+}
+
 func (config JWTConfig) GetClaimsFromToken(token string) (map[string]any, error) {
-	tokenObj, err := jwt.ParseWithClaims(token, &jwt.MapClaims{}, func(token *jwt.Token) (any, error) {
-		if token.Method.Alg() != config.signingMethod.Alg() {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return config.verificationKey, nil
-	})
+	if config.format != nil {
+		return config.getClaimsFromFormat(token)
+	}
+	tokenObj, err := jwt.ParseWithClaims(token, &jwt.MapClaims{}, config.verificationKeyFor, config.parserOptions()...)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -101,6 +419,30 @@ func (config JWTConfig) GetClaimsFromToken(token string) (map[string]any, error)
 	return *claims, nil
 }
 
+func (config JWTConfig) getClaimsFromFormat(token string) (map[string]any, error) {
+	claims, err := config.format.Parse(token)
+	if err != nil {
+		log.Printf("Failed to parse token: %s", err.Error())
+		return nil, err
+	}
+	err = config.validateClaims(claims)
+	if err != nil {
+		log.Printf("Token is invalid: %s", err.Error())
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (config JWTConfig) slimInfo(model User) map[string]any {
+	info := map[string]any{}
+	for _, key := range config.slimInfoKeys {
+		if value, ok := model.Info[key]; ok {
+			info[key] = value
+		}
+	}
+	return info
+}
+
 func GenerateTokenHash(model User, blinder string) string {
 	sha := sha3.New256()
 	bytes, err := hex.DecodeString(model.ID)
@@ -115,56 +457,152 @@ func GenerateTokenHash(model User, blinder string) string {
 	return hex.EncodeToString(sha.Sum(nil))
 }
 
+// SafeExtractUserIdFromHeader accepts only JWT scheme of Authorization header.
 func (config JWTConfig) SafeExtractUserIdFromHeader(h http.Header) (string, error) {
-	// Check if Authorization header exists
-	authHeader, ok := h["Authorization"]
-	if !ok || len(authHeader) == 0 {
+	authHeader := h.Get("Authorization")
+	if authHeader == "" {
 		return "", errors.New("authorization header is missing")
 	}
-
-	// Split the Authorization header into parts
-	tokenParts := strings.Split(authHeader[0], " ")
-	if len(tokenParts) != 2 || tokenParts[0] != "JWT" {
+	token := parseAuthorization(authHeader, "JWT")
+	if token == "" {
 		return "", errors.New("invalid Authorization header format")
 	}
-
-	token := tokenParts[1]
-
-	claims, err := config.GetClaimsFromToken(token)
+	info, err := config.ParseToken(token)
 	if err != nil {
 		return "", err
 	}
-
-	user, ok := claims["user"].(map[string]any)
-	if !ok {
-		return "", errors.New("user claim is missing or invalid")
-	}
-
-	userIdFromJwt, ok := user["id"].(string)
-	if !ok {
+	if info.User.ID == "" {
 		return "", errors.New("user ID is missing or invalid")
 	}
-
-	return userIdFromJwt, nil
+	return info.User.ID, nil
 }
 
+// GetValidUserFromToken validates the token the same way as ValidateToken.
+// DPoP proof of bound tokens can't be checked here, use UserMiddlewareFactory for them.
 func (config JWTConfig) GetValidUserFromToken(token string) (*User, error) {
+	info, err := config.ValidateToken(context.Background(), token)
+	if err != nil {
+		return nil, err
+	}
+	return &info.User, nil
+}
+
+// ParseToken checks signature and hash of the token without looking into any storage.
+func (config JWTConfig) ParseToken(token string) (*TokenInfo, error) {
 	claims, err := config.GetClaimsFromToken(token)
 	if err != nil {
 		return nil, err
 	}
-	userBytes, err := json.Marshal(claims["user"])
+	user, err := userFromClaims(claims)
 	if err != nil {
 		return nil, err
 	}
+
+	if GenerateTokenHash(user, config.blinder) != claims["hash"] {
+		return nil, errors.New("invalid token hash")
+	}
+	info := TokenInfo{User: user, Claims: claims}
+	info.ID, _ = claims["jti"].(string)
+	info.SessionID, _ = claims["sid"].(string)
+	if version, ok := claims["ver"].(float64); ok {
+		info.User.TokenVersion = int64(version)
+	}
+	if cnf, ok := claims["cnf"].(map[string]any); ok {
+		info.KeyThumbprint, _ = cnf["jkt"].(string)
+	}
+	if act, ok := claims["act"].(map[string]any); ok {
+		actorId, _ := act["sub"].(string)
+		readOnly, _ := claims["read_only"].(bool)
+		info.Actor = &Actor{ID: actorId, ReadOnly: readOnly}
+		info.User.Actor = info.Actor
+	}
+	if scope, ok := claims["scope"].(string); ok {
+		info.Scope = parseScope(scope)
+	}
+	info.ClientID, _ = claims["client_id"].(string)
+	info.AuthMethods = stringsFromClaim(claims["amr"])
+	if acr, ok := claims["acr"].(string); ok {
+		info.AuthLevel, _ = strconv.Atoi(acr)
+	}
+	if authTime, ok := claims["auth_time"].(float64); ok {
+		info.AuthTime = time.Unix(int64(authTime), 0)
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		info.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	return &info, nil
+}
+
+// userFromClaims reads the whole user or only sub and info of slim claims.
+func userFromClaims(claims map[string]any) (User, error) {
 	var user User
+	if _, ok := claims["user"]; !ok {
+		sub, ok := claims["sub"].(string)
+		if !ok {
+			return user, errors.New("user claim is missing or invalid")
+		}
+		user.ID = sub
+		user.Info, _ = claims["info"].(map[string]any)
+		user.Roles = stringsFromClaim(claims["roles"])
+		user.Permissions = stringsFromClaim(claims["permissions"])
+		return user, nil
+	}
+	userBytes, err := json.Marshal(claims["user"])
+	if err != nil {
+		return user, err
+	}
 	err = json.Unmarshal(userBytes, &user)
+	return user, err
+}
+
+func stringsFromClaim(claim any) []string {
+	values, _ := claim.([]any)
+	var result []string
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
+// ValidateToken parses the token and checks its state in configured storages.
+func (config JWTConfig) ValidateToken(ctx context.Context, token string) (*TokenInfo, error) {
+	info, err := config.ParseToken(token)
 	if err != nil {
 		return nil, err
 	}
+	if config.revocations != nil {
+		err = checkRevocation(ctx, config.revocations, info)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.tokenVersions != nil {
+		version, err := config.tokenVersions.GetTokenVersion(ctx, info.User.ID)
+		if err != nil {
+			return nil, err
+		}
+		if version != info.User.TokenVersion {
+			return nil, tokenVersionOutdated
+		}
+	}
+	if config.sessions != nil {
+		err = checkSession(ctx, config.sessions, info)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
 
-	if GenerateTokenHash(user, config.blinder) != claims["hash"] {
-		return nil, errors.New("invalid token hash")
+// RevokeToken makes the token invalid until it expires. It requires revocation store.
+func (config JWTConfig) RevokeToken(ctx context.Context, info *TokenInfo) error {
+	if config.revocations == nil {
+		return errors.New("revocation store is not configured")
+	}
+	if info.ID == "" {
+		return errors.New("token has no id")
 	}
-	return &user, nil
+	return config.revocations.Revoke(ctx, info.ID, info.ExpiresAt)
 }
@@ -104,6 +104,173 @@ func TestGetValidUserFromToken(t *testing.T) {
 	}
 }
 
+func TestGenerateTokenWithTTL(t *testing.T) {
+	user := User{ID: bson.NewObjectID().Hex()}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetAccessTokenTTL(time.Minute)
+
+	token, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	claims, err := jwtCfg.GetClaimsFromToken(token)
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		t.Fatal("iat claim is missing")
+	}
+	if _, ok := claims["nbf"].(float64); !ok {
+		t.Fatal("nbf claim is missing")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		t.Fatal("exp claim is missing")
+	}
+	if exp-iat != time.Minute.Seconds() {
+		t.Errorf("unexpected token lifetime: %v", exp-iat)
+	}
+
+	jwtCfg.SetAccessTokenTTL(0)
+	token, err = jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	claims, err = jwtCfg.GetClaimsFromToken(token)
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if _, ok := claims["exp"]; ok {
+		t.Error("expected no exp claim without access token TTL")
+	}
+}
+
+func TestGenerateRefreshToken(t *testing.T) {
+	token, hash, err := GenerateRefreshToken()
+	if err != nil {
+		t.Fatalf("failed to generate refresh token: %v", err)
+	}
+	if token == "" || hash == "" {
+		t.Fatal("expected non-empty refresh token and hash")
+	}
+	if HashRefreshToken(token) != hash {
+		t.Error("refresh token hash mismatch")
+	}
+	another, _, err := GenerateRefreshToken()
+	if err != nil {
+		t.Fatalf("failed to generate refresh token: %v", err)
+	}
+	if another == token {
+		t.Error("expected unique refresh tokens")
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	user := User{ID: bson.NewObjectID().Hex()}
+
+	oldPublic, oldPrivate, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key pair: %v", err)
+	}
+	newPublic, newPrivate, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key pair: %v", err)
+	}
+
+	keySet := NewKeySet(JWTKey{ID: "old", SigningMethod: jwt.SigningMethodEdDSA, SigningKey: oldPrivate, VerificationKey: oldPublic})
+	jwtCfg := NewJWTConfigWithKeySet(keySet, "test-blinder")
+
+	oldToken, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	err = keySet.Rotate(JWTKey{ID: "new", SigningMethod: jwt.SigningMethodEdDSA, SigningKey: newPrivate, VerificationKey: newPublic})
+	if err != nil {
+		t.Fatalf("failed to rotate key: %v", err)
+	}
+
+	newToken, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if parsed.Header["kid"] != "new" {
+		t.Errorf("expected kid new, got %v", parsed.Header["kid"])
+	}
+
+	if _, err := jwtCfg.GetValidUserFromToken(oldToken); err != nil {
+		t.Errorf("expected token signed with old key to be valid before retirement: %v", err)
+	}
+	if _, err := jwtCfg.GetValidUserFromToken(newToken); err != nil {
+		t.Errorf("expected token signed with new key to be valid: %v", err)
+	}
+
+	if err := keySet.Retire("new"); err == nil {
+		t.Error("expected error when retiring active key")
+	}
+	if err := keySet.Retire("old"); err != nil {
+		t.Fatalf("failed to retire key: %v", err)
+	}
+	if _, err := jwtCfg.GetValidUserFromToken(oldToken); err == nil {
+		t.Error("expected token signed with retired key to be rejected")
+	}
+	if _, err := jwtCfg.GetValidUserFromToken(newToken); err != nil {
+		t.Errorf("expected token signed with new key to be valid: %v", err)
+	}
+}
+
+func TestIssuerAndAudience(t *testing.T) {
+	user := User{ID: bson.NewObjectID().Hex()}
+	key := []byte("shared-secret-key")
+
+	serviceA := NewJWTConfig(jwt.SigningMethodHS256, key, key, "test-blinder")
+	serviceA.SetIssuer("accounts")
+	serviceA.SetAudience("service-a")
+
+	serviceB := serviceA.Copy()
+	serviceB.SetAudience("service-b")
+
+	token, err := serviceA.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	claims, err := serviceA.GetClaimsFromToken(token)
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if claims["iss"] != "accounts" {
+		t.Errorf("expected issuer accounts, got %v", claims["iss"])
+	}
+
+	if _, err := serviceB.GetValidUserFromToken(token); err == nil {
+		t.Error("expected token of service A to be rejected by service B")
+	}
+
+	anotherIssuer := serviceA.Copy()
+	anotherIssuer.SetIssuer("auth")
+	if _, err := anotherIssuer.GetValidUserFromToken(token); err == nil {
+		t.Error("expected token of another issuer to be rejected")
+	}
+
+	withoutAudience := NewJWTConfig(jwt.SigningMethodHS256, key, key, "test-blinder")
+	withoutAudience.SetIssuer("accounts")
+	legacyToken, err := withoutAudience.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if _, err := serviceA.GetValidUserFromToken(legacyToken); err == nil {
+		t.Error("expected token without audience to be rejected when audience is configured")
+	}
+}
+
 func TestExpiredToken(t *testing.T) {
 	user := User{ID: "abcdef1234567890abcdef1234567890"}
 	blinder := "expired-blinder"
@@ -0,0 +1,112 @@
+package goauthlib
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// JWTKey is a named key pair. ID is sent in the kid header of tokens signed with it.
+// When Signer is set, it is used instead of SigningKey and its public key is used when VerificationKey is nil.
+type JWTKey struct {
+	ID              string
+	SigningMethod   jwt.SigningMethod
+	SigningKey      any
+	VerificationKey any
+	Signer          Signer
+}
+
+func (key JWTKey) verificationKey() any {
+	if key.VerificationKey == nil && key.Signer != nil {
+		return key.Signer.PublicKey()
+	}
+	return key.VerificationKey
+}
+
+// KeySet holds verification keys looked up by kid and one active signing key.
+// It is safe for concurrent use, so keys can be rotated while the service is running:
+// add and activate a new key, keep the old one until its tokens expire, then retire it.
+type KeySet struct {
+	mu       sync.RWMutex
+	keys     map[string]JWTKey
+	activeID string
+}
+
+func NewKeySet(active JWTKey, verificationOnly ...JWTKey) *KeySet {
+	keySet := &KeySet{keys: map[string]JWTKey{}}
+	for _, key := range verificationOnly {
+		keySet.keys[key.ID] = key
+	}
+	keySet.keys[active.ID] = active
+	keySet.activeID = active.ID
+	return keySet
+}
+
+// Add registers a key for verification only.
+func (s *KeySet) Add(key JWTKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = key
+}
+
+// Activate makes already added key the signing one. The previous key keeps verifying tokens.
+func (s *KeySet) Activate(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return fmt.Errorf("unknown key id: %s", id)
+	}
+	if key.SigningKey == nil && key.Signer == nil {
+		return fmt.Errorf("key %s has no signing key", id)
+	}
+	s.activeID = id
+	return nil
+}
+
+// Rotate adds a key and makes it the signing one.
+func (s *KeySet) Rotate(key JWTKey) error {
+	s.Add(key)
+	return s.Activate(key.ID)
+}
+
+// Retire removes a key, so tokens signed with it are not accepted anymore.
+func (s *KeySet) Retire(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id == s.activeID {
+		return errors.New("can't retire active key")
+	}
+	delete(s.keys, id)
+	return nil
+}
+
+func (s *KeySet) Active() JWTKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[s.activeID]
+}
+
+func (s *KeySet) Get(id string) (JWTKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[id]
+	return key, ok
+}
+
+// List returns all keys sorted by ID.
+func (s *KeySet) List() []JWTKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]JWTKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b JWTKey) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return keys
+}
@@ -0,0 +1,182 @@
+package goauthlib
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/techpro-studio/gohttplib"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MagicLinkDelivery sends login links, e.g. by email.
+type MagicLinkDelivery interface {
+	SendMagicLink(ctx context.Context, destination, link string) error
+}
+
+// MagicLinkChallenge is returned to the device which requested a link bound to it, see SetMagicLinkDeviceBinding.
+type MagicLinkChallenge struct {
+	// DeviceToken is kept by the requesting device and sent back with the link, it is never a part of the link.
+	DeviceToken string
+	// ConfirmationCode is shown by the requesting device, it is entered when the link is opened on another device.
+	ConfirmationCode string
+}
+
+// MagicLinkRequest is the login with a link. DeviceToken or ConfirmationCode is required for links bound
+// to the requesting device.
+type MagicLinkRequest struct {
+	Token            string
+	DeviceToken      string
+	ConfirmationCode string
+}
+
+// magicLinkPayload is signed part of the link. Nonce is checked against the MagicLinkAction verification of the entity,
+// so the link is single use and a new link invalidates it. Codes sent to the entity are kept apart.
+type magicLinkPayload struct {
+	Type      string `json:"typ"`
+	Value     string `json:"val"`
+	Nonce     string `json:"nonce"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	// Device is HMAC of the device token of the requesting device, see SetMagicLinkDeviceBinding.
+	Device string `json:"dev,omitempty"`
+	// Confirmation is HMAC of the code shown on the requesting device. It must be entered on another device.
+	Confirmation string `json:"cnf,omitempty"`
+}
+
+func (useCase *DefaultUseCase) RegisterMagicLinkDelivery(key string, delivery MagicLinkDelivery) {
+	useCase.magicLinkDeliveries[key] = delivery
+}
+
+// SetMagicLinkURL sets the page links point to, token is added as a query parameter.
+// The page should post it to /auth/magic, so link previews and scanners can't consume it.
+func (useCase *DefaultUseCase) SetMagicLinkURL(magicLinkURL string) {
+	useCase.magicLinkURL = magicLinkURL
+}
+
+// SetMagicLinkDeviceBinding binds links to the device which requested them. SendMagicLink returns MagicLinkChallenge:
+// the same device logs in with its device token, another one only with the confirmation code the requesting device shows.
+// Both are issued by the server and never sent with the link, so a leaked link alone is not enough.
+func (useCase *DefaultUseCase) SetMagicLinkDeviceBinding(bind bool) {
+	useCase.bindMagicLinkToDevice = bind
+}
+
+// SendMagicLink returns the challenge when links are bound to the device, see SetMagicLinkDeviceBinding.
+func (useCase *DefaultUseCase) SendMagicLink(ctx context.Context, entity AuthorizationEntity) (*MagicLinkChallenge, error) {
+	delivery := useCase.magicLinkDeliveries[entity.Type]
+	if delivery == nil || useCase.magicLinkURL == "" {
+		return nil, gohttplib.HTTP400("magic links are not configured for " + entity.Type)
+	}
+	err := useCase.checkSendQuota(ctx, entity)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := newTokenId()
+	if err != nil {
+		return nil, err
+	}
+	payload := magicLinkPayload{Type: entity.Type, Value: entity.Value, Nonce: nonce}
+	if useCase.codeTTL > 0 {
+		payload.ExpiresAt = time.Now().Add(useCase.codeTTL).Unix()
+	}
+	var challenge *MagicLinkChallenge
+	if useCase.bindMagicLinkToDevice {
+		challenge = &MagicLinkChallenge{}
+		challenge.DeviceToken, err = newTokenId()
+		if err != nil {
+			return nil, err
+		}
+		challenge.ConfirmationCode, err = useCase.codeGenerator.Generate(entity.Type)
+		if err != nil {
+			return nil, err
+		}
+		payload.Device = useCase.hashCode(challenge.DeviceToken)
+		payload.Confirmation = useCase.hashCode(challenge.ConfirmationCode)
+	}
+	token, err := useCase.signMagicLink(payload)
+	if err != nil {
+		return nil, err
+	}
+	link, err := url.Parse(useCase.magicLinkURL)
+	if err != nil {
+		return nil, err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	useCase.repository.CreateActionVerificationForEntity(ctx, entity, MagicLinkAction, useCase.hashCode(nonce))
+	err = delivery.SendMagicLink(ctx, entity.Value, link.String())
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// AuthenticateWithMagicLink logs in like AuthenticateWithCode. Links bound to the device need its device token
+// or the confirmation code, wrong codes count as attempts of the verification.
+func (useCase *DefaultUseCase) AuthenticateWithMagicLink(ctx context.Context, request MagicLinkRequest) (*Response, error) {
+	payload, err := useCase.parseMagicLink(request.Token)
+	if err != nil {
+		return nil, err
+	}
+	if payload.ExpiresAt != 0 && time.Now().Unix() > payload.ExpiresAt {
+		return nil, codeExpired
+	}
+	entity := AuthorizationEntity{Type: payload.Type, Value: payload.Value}
+	verification := useCase.repository.GetActionVerificationForEntity(ctx, entity, MagicLinkAction)
+	if verification == nil {
+		return nil, invalidMagicLink
+	}
+	otherDevice := payload.Device != "" && !useCase.hashMatches(request.DeviceToken, payload.Device)
+	if otherDevice && request.ConfirmationCode == "" {
+		return nil, magicLinkConfirmationRequired
+	}
+	err = useCase.checkVerification(ctx, verification, payload.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	if otherDevice && !useCase.hashMatches(useCase.CodeSpec(entity.Type).Normalize(request.ConfirmationCode), payload.Confirmation) {
+		return nil, invalidConfirmationCode
+	}
+	return useCase.authenticateEntity(ctx, entity, verification, AuthMethodMagicLink)
+}
+
+func (useCase *DefaultUseCase) hashMatches(value, hash string) bool {
+	return value != "" && hash != "" && subtle.ConstantTimeCompare([]byte(useCase.hashCode(value)), []byte(hash)) == 1
+}
+
+func (useCase *DefaultUseCase) signMagicLink(payload magicLinkPayload) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return encoded + "." + useCase.magicLinkSignature(encoded), nil
+}
+
+func (useCase *DefaultUseCase) parseMagicLink(token string) (*magicLinkPayload, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(useCase.magicLinkSignature(encoded))) {
+		return nil, invalidMagicLink
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalidMagicLink
+	}
+	var payload magicLinkPayload
+	err = json.Unmarshal(data, &payload)
+	if err != nil || payload.Nonce == "" {
+		return nil, invalidMagicLink
+	}
+	return &payload, nil
+}
+
+// magicLinkSignature is keyed with the code secret, the prefix separates it from hashes of codes.
+func (useCase *DefaultUseCase) magicLinkSignature(encoded string) string {
+	mac := hmac.New(sha256.New, useCase.codeSecret)
+	mac.Write([]byte("magic-link:" + encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,197 @@
+package goauthlib
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type testMagicLinkDelivery struct {
+	links []string
+}
+
+func (d *testMagicLinkDelivery) SendMagicLink(ctx context.Context, destination, link string) error {
+	d.links = append(d.links, link)
+	return nil
+}
+
+// testMagicLinkRepository keeps verifications of actions next to the code of testVerificationRepository.
+type testMagicLinkRepository struct {
+	testVerificationRepository
+	actions map[string]*Verification
+}
+
+func (r *testMagicLinkRepository) CreateActionVerificationForEntity(ctx context.Context, entity AuthorizationEntity, action, codeHash string) {
+	r.actions[action+":"+entity.Value] = &Verification{ID: bson.NewObjectID().Hex(), CodeHash: codeHash, Destination: entity.Value, DestinationType: entity.Type, Timestamp: time.Now().Unix()}
+}
+
+func (r *testMagicLinkRepository) GetActionVerificationForEntity(ctx context.Context, entity AuthorizationEntity, action string) *Verification {
+	return r.actions[action+":"+entity.Value]
+}
+
+func (r *testMagicLinkRepository) IncrementVerificationAttempts(ctx context.Context, id string) int {
+	for _, verification := range r.actions {
+		if verification.ID == id {
+			verification.Attempts++
+			return verification.Attempts
+		}
+	}
+	return r.testVerificationRepository.IncrementVerificationAttempts(ctx, id)
+}
+
+func (r *testMagicLinkRepository) DeleteVerification(ctx context.Context, id string) {
+	for key, verification := range r.actions {
+		if verification.ID == id {
+			delete(r.actions, key)
+			return
+		}
+	}
+	r.testVerificationRepository.DeleteVerification(ctx, id)
+}
+
+func newMagicLinkUseCase() (*DefaultUseCase, *testMagicLinkRepository, *testMagicLinkDelivery) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	delivery := &testMagicLinkDelivery{}
+	repository := &testMagicLinkRepository{testVerificationRepository: testVerificationRepository{testUserRepository: testUserRepository{users: map[string]*User{}}}, actions: map[string]*Verification{}}
+	useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+	useCase.RegisterMagicLinkDelivery(EntityTypeEmail, delivery)
+	useCase.SetMagicLinkURL("https://app.test/magic")
+	return useCase, repository, delivery
+}
+
+func magicLinkToken(t *testing.T, link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("invalid link %q: %v", link, err)
+	}
+	return parsed.Query().Get("token")
+}
+
+func TestMagicLink(t *testing.T) {
+	useCase, _, delivery := newMagicLinkUseCase()
+	ctx := context.Background()
+	entity := AuthorizationEntity{Type: EntityTypeEmail, Value: "magic@test.com"}
+
+	challenge, err := useCase.SendMagicLink(ctx, entity)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if challenge != nil {
+		t.Fatalf("expected no challenge without device binding, got %+v", challenge)
+	}
+	token := magicLinkToken(t, delivery.links[0])
+
+	if _, err := useCase.AuthenticateWithMagicLink(ctx, MagicLinkRequest{Token: token + "x"}); err == nil || err.Error() != invalidMagicLink.Error() {
+		t.Fatalf("expected INVALID_MAGIC_LINK for tampered token, got %v", err)
+	}
+	response, err := useCase.AuthenticateWithMagicLink(ctx, MagicLinkRequest{Token: token})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Token == "" || response.User.Entities[0] != entity {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	info, err := useCase.jwtConfig.ParseToken(response.Token)
+	if err != nil || len(info.AuthMethods) != 1 || info.AuthMethods[0] != AuthMethodMagicLink {
+		t.Errorf("expected magic_link amr, got %v, %v", info, err)
+	}
+
+	if _, err := useCase.AuthenticateWithMagicLink(ctx, MagicLinkRequest{Token: token}); err == nil || err.Error() != invalidMagicLink.Error() {
+		t.Fatalf("expected the link to be single use, got %v", err)
+	}
+}
+
+func TestMagicLinkKeepsPendingCode(t *testing.T) {
+	useCase, _, _ := newMagicLinkUseCase()
+	otp := &testOTPDelivery{}
+	useCase.RegisterOTPDelivery(EntityTypeEmail, otp)
+	ctx := context.Background()
+	entity := AuthorizationEntity{Type: EntityTypeEmail, Value: "both@test.com"}
+
+	if err := useCase.SendCode(ctx, entity); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := useCase.SendMagicLink(ctx, entity); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := useCase.AuthenticateWithCode(ctx, entity, otp.sent[0]); err != nil {
+		t.Fatalf("expected the code to work after the link is sent, got %v", err)
+	}
+}
+
+func TestMagicLinkDeviceBinding(t *testing.T) {
+	useCase, _, delivery := newMagicLinkUseCase()
+	useCase.SetMagicLinkDeviceBinding(true)
+	useCase.SetMaxCodeAttempts(3)
+	entity := AuthorizationEntity{Type: EntityTypeEmail, Value: "device@test.com"}
+	// X-Device is chosen by the client, so it must not replace the device token.
+	ctx := WithClientInfo(context.Background(), ClientInfo{Device: "phone"})
+
+	challenge, err := useCase.SendMagicLink(ctx, entity)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if challenge == nil || challenge.DeviceToken == "" || challenge.ConfirmationCode == "" {
+		t.Fatalf("expected challenge for the requesting device, got %+v", challenge)
+	}
+	token := magicLinkToken(t, delivery.links[0])
+	if strings.Contains(delivery.links[0], challenge.DeviceToken) {
+		t.Fatal("expected the device token not to be sent with the link")
+	}
+
+	if _, err := useCase.AuthenticateWithMagicLink(ctx, MagicLinkRequest{Token: token}); err == nil || err.Error() != magicLinkConfirmationRequired.Error() {
+		t.Fatalf("expected CONFIRMATION_REQUIRED without device token, got %v", err)
+	}
+	if _, err := useCase.AuthenticateWithMagicLink(ctx, MagicLinkRequest{Token: token, DeviceToken: "guessed"}); err == nil || err.Error() != magicLinkConfirmationRequired.Error() {
+		t.Fatalf("expected CONFIRMATION_REQUIRED with another device token, got %v", err)
+	}
+	wrongCode := "000000"
+	if challenge.ConfirmationCode == wrongCode {
+		wrongCode = "111111"
+	}
+	if _, err := useCase.AuthenticateWithMagicLink(ctx, MagicLinkRequest{Token: token, ConfirmationCode: wrongCode}); err == nil || err.Error() != invalidConfirmationCode.Error() {
+		t.Fatalf("expected INVALID_CONFIRMATION_CODE, got %v", err)
+	}
+	if _, err := useCase.AuthenticateWithMagicLink(ctx, MagicLinkRequest{Token: token, ConfirmationCode: challenge.ConfirmationCode}); err != nil {
+		t.Fatalf("expected login with the code of the requesting device, got %v", err)
+	}
+
+	challenge, err = useCase.SendMagicLink(ctx, entity)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	token = magicLinkToken(t, delivery.links[1])
+	for i := 0; i < 3; i++ {
+		if _, err := useCase.AuthenticateWithMagicLink(ctx, MagicLinkRequest{Token: token, ConfirmationCode: wrongCode}); err == nil || err.Error() != invalidConfirmationCode.Error() {
+			t.Fatalf("attempt %d: expected INVALID_CONFIRMATION_CODE, got %v", i+1, err)
+		}
+	}
+	if _, err := useCase.AuthenticateWithMagicLink(ctx, MagicLinkRequest{Token: token, DeviceToken: challenge.DeviceToken}); err == nil || err.Error() != tooManyAttempts.Error() {
+		t.Fatalf("expected wrong codes to lock the link, got %v", err)
+	}
+
+	challenge, err = useCase.SendMagicLink(ctx, entity)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := useCase.AuthenticateWithMagicLink(ctx, MagicLinkRequest{Token: magicLinkToken(t, delivery.links[2]), DeviceToken: challenge.DeviceToken}); err != nil {
+		t.Fatalf("expected login on the requesting device, got %v", err)
+	}
+}
+
+func TestMagicLinkExpired(t *testing.T) {
+	useCase, _, _ := newMagicLinkUseCase()
+	token, err := useCase.signMagicLink(magicLinkPayload{Type: EntityTypeEmail, Value: "expired@test.com", Nonce: "nonce", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = useCase.AuthenticateWithMagicLink(context.Background(), MagicLinkRequest{Token: token})
+	if err == nil || err.Error() != codeExpired.Error() {
+		t.Fatalf("expected CODE_EXPIRED, got %v", err)
+	}
+}
@@ -4,45 +4,170 @@ import (
 	"context"
 	"github.com/techpro-studio/gohttplib"
 	"net/http"
-	"strings"
+	"slices"
 )
 
 const CurrentUserContextKey = "current_user_key"
+const CurrentTokenContextKey = "current_token_key"
 
 func UserMiddlewareFactory(config JWTConfig) gohttplib.Middleware {
+	return UserMiddlewareFactoryWithExtractors(config, DefaultTokenExtractors()...)
+}
+
+// UserMiddlewareFactoryWithCookie also accepts the token cookie of browser apps, see Transport.SetCookieConfig.
+// Use it with CSRFMiddlewareFactory.
+func UserMiddlewareFactoryWithCookie(config JWTConfig, cookie CookieConfig) gohttplib.Middleware {
+	return UserMiddlewareFactoryWithExtractors(config, append(DefaultTokenExtractors(), CookieExtractor(cookie.TokenName))...)
+}
+
+// UserMiddlewareFactoryWithExtractors takes the token from the first extractor which finds it.
+// Scoped tokens of token exchange are rejected, see ScopedUserMiddlewareFactory.
+func UserMiddlewareFactoryWithExtractors(config JWTConfig, extractors ...TokenExtractor) gohttplib.Middleware {
+	return userMiddleware(config, extractors, denyScopedToken)
+}
+
+// ScopedUserMiddlewareFactory is UserMiddlewareFactory of services called with exchanged tokens, see
+// DefaultUseCase.ExchangeToken. Tokens must allow all the scopes, tokens without scope are not limited.
+func ScopedUserMiddlewareFactory(config JWTConfig, scopes ...string) gohttplib.Middleware {
+	return userMiddleware(config, DefaultTokenExtractors(), func(info *TokenInfo) error {
+		return requireScope(info, scopes)
+	})
+}
+
+func userMiddleware(config JWTConfig, extractors []TokenExtractor, accept func(info *TokenInfo) error) gohttplib.Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			tokenStr := GetTokenFromRequest(req)
+			tokenStr := ExtractToken(req, extractors...)
 			if tokenStr == "" {
 				gohttplib.HTTP401().Write(w)
 				return
 			}
-			user, err := config.GetValidUserFromToken(tokenStr)
+			info, err := authenticate(config, req, tokenStr)
+			if err == nil {
+				err = accept(info)
+			}
 			if err != nil {
+				gohttplib.SafeConvertToServerError(err).Write(w)
+				return
+			}
+			next.ServeHTTP(w, req.WithContext(WithTokenInfo(req.Context(), info)))
+		})
+	}
+}
+
+func denyScopedToken(info *TokenInfo) error {
+	if info.IsScoped() {
+		return scopedTokenForbidden
+	}
+	return nil
+}
+
+func requireScope(info *TokenInfo, scopes []string) error {
+	if !info.HasScope(scopes...) {
+		return scopeRequired
+	}
+	return nil
+}
+
+// MaybeUserMiddlewareFactory attaches the user when the request has a valid token and passes any other request as is.
+func MaybeUserMiddlewareFactory(config JWTConfig) gohttplib.Middleware {
+	return MaybeUserMiddlewareFactoryWithExtractors(config, DefaultTokenExtractors()...)
+}
+
+func MaybeUserMiddlewareFactoryWithExtractors(config JWTConfig, extractors ...TokenExtractor) gohttplib.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			tokenStr := ExtractToken(req, extractors...)
+			if tokenStr == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+			info, err := authenticate(config, req, tokenStr)
+			if err != nil || info.IsScoped() {
+				next.ServeHTTP(w, req)
+				return
+			}
+			next.ServeHTTP(w, req.WithContext(WithTokenInfo(req.Context(), info)))
+		})
+	}
+}
+
+func authenticate(config JWTConfig, req *http.Request, tokenStr string) (*TokenInfo, error) {
+	info, err := config.ValidateToken(req.Context(), tokenStr)
+	if err != nil {
+		return nil, gohttplib.HTTP401()
+	}
+	err = config.checkDPoP(req, tokenStr, info)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// WithTokenInfo puts the validated token and its user to the context, e.g. by non-HTTP transports.
+func WithTokenInfo(ctx context.Context, info *TokenInfo) context.Context {
+	ctx = context.WithValue(ctx, CurrentUserContextKey, &info.User)
+	return context.WithValue(ctx, CurrentTokenContextKey, info)
+}
+
+// RequireRole allows users with any of the roles. It must be used after UserMiddlewareFactory.
+func RequireRole(roles ...string) gohttplib.Middleware {
+	return requireUser(roleRequired, func(user *User) bool {
+		return slices.ContainsFunc(roles, user.HasRole)
+	})
+}
+
+// RequirePermission allows users with all the permissions. It must be used after UserMiddlewareFactory.
+func RequirePermission(permissions ...string) gohttplib.Middleware {
+	return requireUser(permissionRequired, func(user *User) bool {
+		for _, permission := range permissions {
+			if !user.HasPermission(permission) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// RequireScope allows tokens which allow all the scopes. It must be used after ScopedUserMiddlewareFactory.
+func RequireScope(scopes ...string) gohttplib.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			info := GetTokenInfoFromRequest(req)
+			if info == nil {
 				gohttplib.HTTP401().Write(w)
+				return
 			}
+			if err := requireScope(info, scopes); err != nil {
+				gohttplib.SafeConvertToServerError(err).Write(w)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+func requireUser(forbidden gohttplib.ServerError, allowed func(user *User) bool) gohttplib.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			user := GetUserFromRequest(req)
 			if user == nil {
 				gohttplib.HTTP401().Write(w)
 				return
 			}
-			ctx := context.WithValue(req.Context(), CurrentUserContextKey, user)
-			next.ServeHTTP(w, req.WithContext(ctx))
+			if !allowed(user) {
+				forbidden.Write(w)
+				return
+			}
+			next.ServeHTTP(w, req)
 		})
 	}
 }
 
+// GetTokenFromRequest keeps the legacy lookup of Authorization header and token URL parameter.
+// Middlewares use DefaultTokenExtractors, which don't read URL parameters.
 func GetTokenFromRequest(req *http.Request) string {
-	tokenStr := ""
-	if AuthHeader := req.Header.Get("Authorization"); AuthHeader != "" {
-		tokenStr = strings.Split(AuthHeader, " ")[1]
-	}
-	if tokenStr == "" {
-		token := gohttplib.GetParameterFromURLInRequest(req, "token")
-		if token != nil {
-			tokenStr = *token
-		}
-	}
-	return tokenStr
+	return ExtractToken(req, AuthorizationExtractor(), QueryExtractor("token"))
 }
 
 func GetUserFromRequestWithPanic(req *http.Request) User {
@@ -54,9 +179,25 @@ func GetUserFromRequestWithPanic(req *http.Request) User {
 }
 
 func GetUserFromRequest(req *http.Request) *User {
-	user, ok := req.Context().Value(CurrentUserContextKey).(*User)
+	return UserFromContext(req.Context())
+}
+
+func UserFromContext(ctx context.Context) *User {
+	user, ok := ctx.Value(CurrentUserContextKey).(*User)
 	if !ok {
 		return nil
 	}
 	return user
 }
+
+func GetTokenInfoFromRequest(req *http.Request) *TokenInfo {
+	return TokenInfoFromContext(req.Context())
+}
+
+func TokenInfoFromContext(ctx context.Context) *TokenInfo {
+	info, ok := ctx.Value(CurrentTokenContextKey).(*TokenInfo)
+	if !ok {
+		return nil
+	}
+	return info
+}
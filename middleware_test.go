@@ -0,0 +1,99 @@
+package goauthlib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestUserMiddlewareStopsOnInvalidToken(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	called := false
+	handler := UserMiddlewareFactory(*jwtCfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("Authorization", "Bearer invalid")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || called {
+		t.Errorf("expected 401 without calling the handler, got %d, called %t", rec.Code, called)
+	}
+}
+
+func TestMaybeUserMiddleware(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	user := User{ID: bson.NewObjectID().Hex()}
+	token, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	var seen *User
+	handler := MaybeUserMiddlewareFactory(*jwtCfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetUserFromRequest(r)
+		w.WriteHeader(http.StatusOK)
+	}))
+	for _, authorization := range []string{"", "Bearer invalid", "Bearer " + token} {
+		seen = nil
+		req := httptest.NewRequest(http.MethodGet, "/feed", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected request %q to pass, got %d", authorization, rec.Code)
+		}
+		if authorization == "Bearer "+token {
+			if seen == nil || seen.ID != user.ID {
+				t.Errorf("expected user for valid token, got %v", seen)
+			}
+		} else if seen != nil {
+			t.Errorf("expected no user for %q", authorization)
+		}
+	}
+}
+
+func TestRequireRoleAndPermission(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	moderator := User{ID: bson.NewObjectID().Hex(), Roles: []string{"moderator"}, Permissions: []string{"posts:read", "posts:delete"}}
+	plain := User{ID: bson.NewObjectID().Hex()}
+
+	cases := []struct {
+		name       string
+		middleware func(http.Handler) http.Handler
+		user       *User
+		status     int
+	}{
+		{"role granted", RequireRole("admin", "moderator"), &moderator, http.StatusOK},
+		{"role missing", RequireRole("admin"), &moderator, http.StatusForbidden},
+		{"permissions granted", RequirePermission("posts:read", "posts:delete"), &moderator, http.StatusOK},
+		{"permission missing", RequirePermission("posts:read"), &plain, http.StatusForbidden},
+		{"no user", RequireRole("moderator"), nil, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tc.user != nil {
+				token, err := jwtCfg.GenerateTokenFromModel(*tc.user)
+				if err != nil {
+					t.Fatalf("failed to generate token: %v", err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			handler := MaybeUserMiddlewareFactory(*jwtCfg)(tc.middleware(ok))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Errorf("expected %d, got %d", tc.status, rec.Code)
+			}
+		})
+	}
+}
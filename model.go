@@ -1,14 +1,35 @@
 package goauthlib
 
+import "slices"
+
 // Response is sent back
 type Response struct {
-	Token    string                 `json:"token"`
-	User     User                   `json:"user"`
-	UserInfo map[string]interface{} `json:"user_info,omitempty"`
+	Token        string                 `json:"token"`
+	RefreshToken string                 `json:"refresh_token,omitempty"`
+	User         User                   `json:"user"`
+	UserInfo     map[string]interface{} `json:"user_info,omitempty"`
 }
 
 var OK = map[string]int{"ok": 1}
 
+// IntrospectionResponse is a token state in RFC 7662 format
+type IntrospectionResponse struct {
+	Active       bool                  `json:"active"`
+	Subject      string                `json:"sub,omitempty"`
+	ExpiresAt    int64                 `json:"exp,omitempty"`
+	IssuedAt     int64                 `json:"iat,omitempty"`
+	Issuer       string                `json:"iss,omitempty"`
+	Audience     []string              `json:"aud,omitempty"`
+	Scope        string                `json:"scope,omitempty"`
+	TokenID      string                `json:"jti,omitempty"`
+	SessionID    string                `json:"sid,omitempty"`
+	TokenType    string                `json:"token_type,omitempty"`
+	Confirmation map[string]string     `json:"cnf,omitempty"`
+	Actor        map[string]string     `json:"act,omitempty"`
+	ClientID     string                `json:"client_id,omitempty"`
+	Entities     []AuthorizationEntity `json:"entities,omitempty"`
+}
+
 const (
 	EntityTypeEmail = "email"
 	EntityTypePhone = "phone"
@@ -19,6 +40,21 @@ type User struct {
 	ID       string                `json:"id"`
 	Entities []AuthorizationEntity `json:"entities"`
 	Info     map[string]any        `json:"info,omitempty"`
+	// Roles and Permissions of the user in the current service, see RequireRole and RequirePermission.
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// TokenVersion is increased to invalidate all tokens of the user. It is sent in the ver claim.
+	TokenVersion int64 `json:"-"`
+	// Actor is set when an admin impersonates the user, see GetActorFromRequest.
+	Actor *Actor `json:"-"`
+}
+
+func (u User) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+func (u User) HasPermission(permission string) bool {
+	return slices.Contains(u.Permissions, permission)
 }
 
 type AuthorizationEntity struct {
@@ -39,9 +75,42 @@ func (e AuthorizationEntity) GetHash() string {
 }
 
 type Verification struct {
-	ID              string
-	Code            string
+	ID string
+	// Code is kept only by records created before codes were hashed, see DefaultUseCase.SetPlaintextCodesUntil.
+	Code string
+	// CodeHash is HMAC of the code, see DefaultUseCase.SetCodeSecret.
+	CodeHash        string
 	Destination     string
 	DestinationType string
 	Timestamp       int64
+	// Attempts is a number of checks of the code, see DefaultUseCase.SetMaxCodeAttempts.
+	Attempts int
+}
+
+// RefreshToken is a stored long-lived token which can be traded for a new token pair once.
+// Only the hash of the token is persisted.
+type RefreshToken struct {
+	ID        string
+	UserID    string
+	SessionID string
+	Hash      string
+	ExpiresAt int64
+	// KeyThumbprint binds the refresh token to the DPoP key of the client which got it.
+	KeyThumbprint string
+	// AuthMethods and AuthTime of the login are kept in tokens issued by refresh.
+	AuthMethods []string
+	AuthTime    int64
+}
+
+// Session is a single login of the user on some device
+type Session struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id"`
+	Device     string `json:"device,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at"`
+	Revoked    bool   `json:"-"`
+	Current    bool   `json:"current"`
 }
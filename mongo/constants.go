@@ -4,3 +4,7 @@ const dbName = "auth"
 const userCollection = "user"
 const oauthDataCollection = "oauth_data"
 const verificationCollection = "verification"
+const refreshTokenCollection = "refresh_token"
+const sessionCollection = "session"
+const revokedTokenCollection = "revoked_token"
+const counterCollection = "counter"
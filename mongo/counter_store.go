@@ -0,0 +1,52 @@
+package mongo
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"time"
+)
+
+// CounterStore keeps counters of goauthlib.SendQuota. Documents are removed by TTL index when windows end.
+type CounterStore struct {
+	Client *mongo.Client
+}
+
+func NewCounterStore(client *mongo.Client) *CounterStore {
+	return &CounterStore{Client: client}
+}
+
+func (store *CounterStore) collection() *mongo.Collection {
+	return store.Client.Database(dbName).Collection(counterCollection)
+}
+
+// EnsureIndexes creates TTL index on the end of the window. Call it once on startup.
+func (store *CounterStore) EnsureIndexes(ctx context.Context) error {
+	_, err := store.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "reset_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Increment starts a new window or increases the counter in a single update, so parallel sends are counted exactly.
+// TTL index removes documents with a delay, so ended windows are also restarted here.
+func (store *CounterStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	now := time.Now()
+	active := bson.M{"$gt": bson.A{"$reset_at", now}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"count":    bson.M{"$cond": bson.A{active, bson.M{"$add": bson.A{"$count", 1}}, 1}},
+		"reset_at": bson.M{"$cond": bson.A{active, "$reset_at", now.Add(window)}},
+	}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var counter struct {
+		Count   int64     `bson:"count"`
+		ResetAt time.Time `bson:"reset_at"`
+	}
+	err := store.collection().FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&counter)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return counter.Count, counter.ResetAt, nil
+}
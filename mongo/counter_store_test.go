@@ -0,0 +1,44 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCounterStore(t *testing.T) {
+	repo, cleanup := setup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := NewCounterStore(repo.Client)
+	if err := store.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := int64(1); i <= 3; i++ {
+		count, resetAt, err := store.Increment(ctx, "key", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != i {
+			t.Fatalf("expected count %d, got %d", i, count)
+		}
+		if time.Until(resetAt) <= 0 {
+			t.Fatalf("expected window to end in the future, got %v", resetAt)
+		}
+	}
+
+	// Ended window starts over
+	if _, _, err := store.Increment(ctx, "short", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	count, _, err := store.Increment(ctx, "short", time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("expected a new window, got count %d", count)
+	}
+}
@@ -6,11 +6,13 @@ import (
 )
 
 type mongoUser struct {
-	ID       bson.ObjectID              `bson:"_id"`
-	Entities []mongoAuthorizationEntity `bson:"entities"`
-	Info     map[string]any             `bson:"info,omitempty"`
-	Deleted  bool                       `bson:"deleted"`
-	Services []string                   `bson:"services"`
+	ID           bson.ObjectID              `bson:"_id"`
+	Entities     []mongoAuthorizationEntity `bson:"entities"`
+	Info         map[string]any             `bson:"info,omitempty"`
+	Deleted      bool                       `bson:"deleted"`
+	Services     []string                   `bson:"services"`
+	TokenVersion int64                      `bson:"token_version"`
+	Roles        map[string][]string        `bson:"roles,omitempty"`
 }
 
 type mongoAuthorizationEntity struct {
@@ -41,9 +43,10 @@ func toDomainUser(m *mongoUser) *auth.User {
 		entities = append(entities, toDomainEntity(e))
 	}
 	return &auth.User{
-		ID:       m.ID.Hex(),
-		Entities: entities,
-		Info:     m.Info,
+		ID:           m.ID.Hex(),
+		Entities:     entities,
+		Info:         m.Info,
+		TokenVersion: m.TokenVersion,
 	}
 }
 
@@ -60,18 +63,21 @@ func toMongoUser(u *auth.User) *mongoUser {
 		panic(err)
 	}
 	return &mongoUser{
-		ID:       id,
-		Entities: entities,
-		Info:     u.Info,
+		ID:           id,
+		Entities:     entities,
+		Info:         u.Info,
+		TokenVersion: u.TokenVersion,
 	}
 }
 
 type mongoVerification struct {
 	ID              bson.ObjectID `bson:"_id"`
-	Code            string        `bson:"code"`
+	Code            string        `bson:"code,omitempty"`
+	CodeHash        string        `bson:"code_hash,omitempty"`
 	Destination     string        `bson:"destination"`
 	DestinationType string        `bson:"destination_type"`
 	Timestamp       int64         `json:"timestamp"`
+	Attempts        int           `bson:"attempts"`
 }
 
 func toMongoVerification(v *auth.Verification) *mongoVerification {
@@ -82,9 +88,11 @@ func toMongoVerification(v *auth.Verification) *mongoVerification {
 	return &mongoVerification{
 		ID:              id,
 		Code:            v.Code,
+		CodeHash:        v.CodeHash,
 		Destination:     v.Destination,
 		DestinationType: v.DestinationType,
 		Timestamp:       v.Timestamp,
+		Attempts:        v.Attempts,
 	}
 }
 
@@ -92,8 +100,60 @@ func toDomainVerification(m *mongoVerification) *auth.Verification {
 	return &auth.Verification{
 		ID:              m.ID.Hex(),
 		Code:            m.Code,
+		CodeHash:        m.CodeHash,
 		Destination:     m.Destination,
 		DestinationType: m.DestinationType,
 		Timestamp:       m.Timestamp,
+		Attempts:        m.Attempts,
+	}
+}
+
+type mongoRefreshToken struct {
+	ID        bson.ObjectID `bson:"_id"`
+	UserID    bson.ObjectID `bson:"user_id"`
+	SessionID string        `bson:"session_id,omitempty"`
+	Hash      string        `bson:"hash"`
+	ExpiresAt int64         `bson:"expires_at"`
+	Service   string        `bson:"service"`
+	JKT       string        `bson:"jkt,omitempty"`
+	AMR       []string      `bson:"amr,omitempty"`
+	AuthTime  int64         `bson:"auth_time,omitempty"`
+}
+
+func toDomainRefreshToken(m *mongoRefreshToken) *auth.RefreshToken {
+	return &auth.RefreshToken{
+		ID:            m.ID.Hex(),
+		UserID:        m.UserID.Hex(),
+		SessionID:     m.SessionID,
+		Hash:          m.Hash,
+		ExpiresAt:     m.ExpiresAt,
+		KeyThumbprint: m.JKT,
+		AuthMethods:   m.AMR,
+		AuthTime:      m.AuthTime,
+	}
+}
+
+type mongoSession struct {
+	ID         bson.ObjectID `bson:"_id"`
+	UserID     bson.ObjectID `bson:"user_id"`
+	Device     string        `bson:"device"`
+	IP         string        `bson:"ip"`
+	UserAgent  string        `bson:"user_agent"`
+	CreatedAt  int64         `bson:"created_at"`
+	LastSeenAt int64         `bson:"last_seen_at"`
+	Revoked    bool          `bson:"revoked"`
+	Service    string        `bson:"service"`
+}
+
+func toDomainSession(m *mongoSession) *auth.Session {
+	return &auth.Session{
+		ID:         m.ID.Hex(),
+		UserID:     m.UserID.Hex(),
+		Device:     m.Device,
+		IP:         m.IP,
+		UserAgent:  m.UserAgent,
+		CreatedAt:  m.CreatedAt,
+		LastSeenAt: m.LastSeenAt,
+		Revoked:    m.Revoked,
 	}
 }
@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/techpro-studio/goauthlib"
 	"github.com/techpro-studio/goauthlib/oauth"
@@ -16,8 +17,9 @@ import (
 const notFoundDocumentError = "mongo: no documents in result"
 
 type Repository struct {
-	Client  *mongo.Client
-	service string
+	Client          *mongo.Client
+	service         string
+	rolePermissions map[string][]string
 }
 
 func (repo *Repository) SoftDeleteUser(ctx context.Context, id bson.ObjectID) error {
@@ -36,44 +38,113 @@ func (repo *Repository) DeleteVerification(ctx context.Context, id string) {
 	}
 }
 
+func (repo *Repository) IncrementVerificationAttempts(ctx context.Context, id string) int {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"attempts": 1})
+	var verification struct {
+		Attempts int `bson:"attempts"`
+	}
+	err := repo.Client.Database(dbName).Collection(verificationCollection).FindOneAndUpdate(ctx, bson.M{"_id": *gomongo.StrToObjId(&id)}, bson.M{"$inc": bson.M{"attempts": 1}}, opts).Decode(&verification)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0
+	}
+	if err != nil {
+		panic(err)
+	}
+	return verification.Attempts
+}
+
 func NewRepository(client *mongo.Client, service string) *Repository {
 	return &Repository{Client: client, service: service}
 }
 
+// SetRolePermissions sets permissions granted by each role. Users get permissions of their roles in the service.
+func (repo *Repository) SetRolePermissions(rolePermissions map[string][]string) {
+	repo.rolePermissions = rolePermissions
+}
+
+// toDomainUser keeps roles of the user only in the service of the repository.
+func (repo *Repository) toDomainUser(m *mongoUser) *goauthlib.User {
+	user := toDomainUser(m)
+	if user == nil {
+		return nil
+	}
+	user.Roles = m.Roles[repo.service]
+	for _, role := range user.Roles {
+		for _, permission := range repo.rolePermissions[role] {
+			if !utils.ContainsString(user.Permissions, permission) {
+				user.Permissions = append(user.Permissions, permission)
+			}
+		}
+	}
+	return user
+}
+
+// Service is a name of the service users are registered in. It fits as JWTConfig audience.
+func (repo *Repository) Service() string {
+	return repo.service
+}
+
 func (repo *Repository) GetVerificationForEntity(ctx context.Context, entity goauthlib.AuthorizationEntity) *goauthlib.Verification {
-	return repo.getOneVerification(ctx, bson.M{"destination": entity.Value, "destination_type": entity.Type, "service": repo.service})
+	return repo.GetActionVerificationForEntity(ctx, entity, "")
 }
 
-func (repo *Repository) CreateVerificationForEntity(ctx context.Context, entity goauthlib.AuthorizationEntity, verificationCode string) {
-	q := bson.M{"destination": entity.Value, "destination_type": entity.Type}
+func (repo *Repository) CreateVerificationForEntity(ctx context.Context, entity goauthlib.AuthorizationEntity, codeHash string) {
+	repo.CreateActionVerificationForEntity(ctx, entity, "", codeHash)
+}
+
+func (repo *Repository) GetActionVerificationForEntity(ctx context.Context, entity goauthlib.AuthorizationEntity, action string) *goauthlib.Verification {
+	q := entityVerificationQuery(entity, action)
+	q["service"] = repo.service
+	return repo.getOneVerification(ctx, q)
+}
+
+func (repo *Repository) CreateActionVerificationForEntity(ctx context.Context, entity goauthlib.AuthorizationEntity, action, codeHash string) {
+	set := bson.M{
+		"destination":      entity.Value,
+		"destination_type": entity.Type,
+		"timestamp":        time.Now().Unix(),
+		"code_hash":        codeHash,
+		"attempts":         0,
+		"service":          repo.service,
+	}
+	if action != "" {
+		set["action"] = action
+	}
 	u := bson.M{
-		"$set": bson.M{
-			"destination":      entity.Value,
-			"destination_type": entity.Type,
-			"timestamp":        time.Now().Unix(),
-			"code":             verificationCode,
-			"service":          repo.service,
-		},
+		"$set": set,
+		// Plaintext code of a record created before hashing is removed with the new code.
+		"$unset": bson.M{"code": ""},
 	}
-	_, err := repo.Client.Database(dbName).Collection(verificationCollection).UpdateOne(ctx, q, u, options.UpdateOne().SetUpsert(true))
+	_, err := repo.Client.Database(dbName).Collection(verificationCollection).UpdateOne(ctx, entityVerificationQuery(entity, action), u, options.UpdateOne().SetUpsert(true))
 	if err != nil {
 		panic(err)
 	}
 }
 
+// entityVerificationQuery matches the code of the entity when action is empty, otherwise the verification of the action.
+func entityVerificationQuery(entity goauthlib.AuthorizationEntity, action string) bson.M {
+	q := bson.M{"destination": entity.Value, "destination_type": entity.Type, "action": bson.M{"$exists": false}}
+	if action != "" {
+		q["action"] = action
+	}
+	return q
+}
+
 func (repo *Repository) GetServiceActionVerification(ctx context.Context, action string) *goauthlib.Verification {
 	return repo.getOneVerification(ctx, bson.M{"action": action, "service": repo.service})
 }
 
-func (repo *Repository) CreateServiceActionVerification(ctx context.Context, action, verificationCode string) {
+func (repo *Repository) CreateServiceActionVerification(ctx context.Context, action, codeHash string) {
 	q := bson.M{"removal": repo.service}
 	u := bson.M{
 		"$set": bson.M{
 			"action":    action,
 			"timestamp": time.Now().Unix(),
-			"code":      verificationCode,
+			"code_hash": codeHash,
+			"attempts":  0,
 			"service":   repo.service,
 		},
+		"$unset": bson.M{"code": ""},
 	}
 	_, err := repo.Client.Database(dbName).Collection(verificationCollection).UpdateOne(ctx, q, u, options.UpdateOne().SetUpsert(true))
 	if err != nil {
@@ -133,7 +204,7 @@ func (repo *Repository) UpsertForEntity(ctx context.Context, entity goauthlib.Au
 	if err != nil {
 		return nil, err
 	}
-	return toDomainUser(&mongoUser), nil
+	return repo.toDomainUser(&mongoUser), nil
 }
 
 func (repo *Repository) CreateForSocial(ctx context.Context, result *oauth.ProviderResult) *goauthlib.User {
@@ -160,7 +231,7 @@ func (repo *Repository) CreateForSocial(ctx context.Context, result *oauth.Provi
 	if err != nil {
 		panic(err)
 	}
-	return toDomainUser(&mongoUser)
+	return repo.toDomainUser(&mongoUser)
 }
 
 func (repo *Repository) getOneUser(ctx context.Context, query bson.M, nullIfNoService bool) *goauthlib.User {
@@ -188,7 +259,7 @@ func (repo *Repository) getOneUser(ctx context.Context, query bson.M, nullIfNoSe
 	if !utils.ContainsString(mongoUser.Services, repo.service) && nullIfNoService {
 		return nil
 	}
-	return toDomainUser(&mongoUser)
+	return repo.toDomainUser(&mongoUser)
 }
 
 func (repo *Repository) getOneVerification(ctx context.Context, query bson.M) *goauthlib.Verification {
@@ -220,7 +291,7 @@ func (repo *Repository) CreateForEntity(ctx context.Context, entity goauthlib.Au
 	if err != nil {
 		panic(err)
 	}
-	return toDomainUser(&mongoUser)
+	return repo.toDomainUser(&mongoUser)
 }
 
 func (repo *Repository) RemoveService(ctx context.Context, id string, softDeleteIfNoServices bool, callback func(ctx context.Context, userId string) error) {
@@ -322,5 +393,91 @@ func (repo *Repository) GetByIdList(ctx context.Context, idList []string) []*goa
 	if err != nil {
 		panic(err)
 	}
-	return gomongo.SliceMap(users, toDomainUser)
+	return gomongo.SliceMap(users, repo.toDomainUser)
+}
+
+func (repo *Repository) CreateRefreshToken(ctx context.Context, token goauthlib.RefreshToken) {
+	mongoToken := mongoRefreshToken{
+		ID:        bson.NewObjectID(),
+		UserID:    *gomongo.StrToObjId(&token.UserID),
+		SessionID: token.SessionID,
+		Hash:      token.Hash,
+		ExpiresAt: token.ExpiresAt,
+		Service:   repo.service,
+		JKT:       token.KeyThumbprint,
+		AMR:       token.AuthMethods,
+		AuthTime:  token.AuthTime,
+	}
+	_, err := repo.Client.Database(dbName).Collection(refreshTokenCollection).InsertOne(ctx, mongoToken)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ConsumeRefreshToken atomically removes a refresh token, so it can be traded only once.
+func (repo *Repository) ConsumeRefreshToken(ctx context.Context, hash string) *goauthlib.RefreshToken {
+	var mongoToken mongoRefreshToken
+	err := repo.Client.Database(dbName).Collection(refreshTokenCollection).FindOneAndDelete(ctx, bson.M{"hash": hash, "service": repo.service}).Decode(&mongoToken)
+	if err != nil {
+		if err.Error() != notFoundDocumentError {
+			panic(err)
+		}
+		return nil
+	}
+	return toDomainRefreshToken(&mongoToken)
+}
+
+func (repo *Repository) DeleteRefreshTokensForUser(ctx context.Context, userId string) {
+	_, err := repo.Client.Database(dbName).Collection(refreshTokenCollection).DeleteMany(ctx, bson.M{"user_id": *gomongo.StrToObjId(&userId), "service": repo.service})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (repo *Repository) GetTokenVersion(ctx context.Context, userId string) (int64, error) {
+	objId, err := bson.ObjectIDFromHex(userId)
+	if err != nil {
+		return 0, err
+	}
+	var user struct {
+		TokenVersion int64 `bson:"token_version"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"token_version": 1})
+	err = repo.Client.Database(dbName).Collection(userCollection).FindOne(ctx, bson.M{"_id": objId, "deleted": bson.M{"$ne": true}}, opts).Decode(&user)
+	if err != nil {
+		return 0, err
+	}
+	return user.TokenVersion, nil
+}
+
+func (repo *Repository) IncrementTokenVersion(ctx context.Context, userId string) int64 {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"token_version": 1})
+	var user struct {
+		TokenVersion int64 `bson:"token_version"`
+	}
+	err := repo.Client.Database(dbName).Collection(userCollection).FindOneAndUpdate(ctx, bson.M{"_id": *gomongo.StrToObjId(&userId)}, bson.M{"$inc": bson.M{"token_version": 1}}, opts).Decode(&user)
+	if err != nil {
+		panic(err)
+	}
+	return user.TokenVersion
+}
+
+// GrantRole adds the role of the user in the service. It reports whether the user didn't have it.
+func (repo *Repository) GrantRole(ctx context.Context, userId string, role string) bool {
+	res, err := repo.Client.Database(dbName).Collection(userCollection).
+		UpdateOne(ctx, bson.M{"_id": *gomongo.StrToObjId(&userId)}, bson.M{"$addToSet": bson.M{"roles." + repo.service: role}})
+	if err != nil {
+		panic(err)
+	}
+	return res.ModifiedCount > 0
+}
+
+// RevokeRole removes the role of the user in the service. It reports whether the user had it.
+func (repo *Repository) RevokeRole(ctx context.Context, userId string, role string) bool {
+	res, err := repo.Client.Database(dbName).Collection(userCollection).
+		UpdateOne(ctx, bson.M{"_id": *gomongo.StrToObjId(&userId)}, bson.M{"$pull": bson.M{"roles." + repo.service: role}})
+	if err != nil {
+		panic(err)
+	}
+	return res.ModifiedCount > 0
 }
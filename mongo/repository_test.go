@@ -10,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"testing"
+	"time"
 )
 
 func GetTestMongoDB(t *testing.T, ctx context.Context) (*mongo.Database, func()) {
@@ -53,20 +54,75 @@ func TestCreateAndGetVerificationForEntity(t *testing.T) {
 		Value: "test@example.com",
 	}
 
-	repo.CreateVerificationForEntity(ctx, entity, "123456")
+	repo.CreateVerificationForEntity(ctx, entity, "code-hash")
 
 	v := repo.GetVerificationForEntity(ctx, entity)
 	if v == nil {
 		t.Fatal("expected verification but got nil")
 	}
-	if v.Code != "123456" {
-		t.Fatalf("unexpected verification code: %s", v.Code)
+	if v.CodeHash != "code-hash" || v.Code != "" {
+		t.Fatalf("unexpected verification code: %s, hash: %s", v.Code, v.CodeHash)
 	}
 	if v.Destination != "test@example.com" {
 		t.Fatalf("unexpected destination: %s", v.Destination)
 	}
 }
 
+func TestActionVerificationForEntity(t *testing.T) {
+	repo, cleanup := setup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	entity := goauthlib.AuthorizationEntity{Type: goauthlib.EntityTypeEmail, Value: "action@example.com"}
+
+	repo.CreateVerificationForEntity(ctx, entity, "code-hash")
+	repo.CreateActionVerificationForEntity(ctx, entity, goauthlib.MagicLinkAction, "nonce-hash")
+
+	if v := repo.GetVerificationForEntity(ctx, entity); v == nil || v.CodeHash != "code-hash" {
+		t.Fatalf("expected the code to be kept, got %+v", v)
+	}
+	action := repo.GetActionVerificationForEntity(ctx, entity, goauthlib.MagicLinkAction)
+	if action == nil || action.CodeHash != "nonce-hash" {
+		t.Fatalf("expected the verification of the action, got %+v", action)
+	}
+	repo.DeleteVerification(ctx, action.ID)
+	if v := repo.GetVerificationForEntity(ctx, entity); v == nil {
+		t.Fatal("expected the code to stay after the action verification is deleted")
+	}
+}
+
+func TestIncrementVerificationAttempts(t *testing.T) {
+	repo, cleanup := setup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	entity := goauthlib.AuthorizationEntity{
+		Type:  goauthlib.EntityTypeEmail,
+		Value: "attempts@example.com",
+	}
+
+	repo.CreateVerificationForEntity(ctx, entity, "123456")
+	v := repo.GetVerificationForEntity(ctx, entity)
+	if v.Attempts != 0 {
+		t.Fatalf("expected 0 attempts, got %d", v.Attempts)
+	}
+	repo.IncrementVerificationAttempts(ctx, v.ID)
+	if attempts := repo.IncrementVerificationAttempts(ctx, v.ID); attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+
+	// A new code resets attempts
+	repo.CreateVerificationForEntity(ctx, entity, "654321")
+	if v = repo.GetVerificationForEntity(ctx, entity); v.Attempts != 0 {
+		t.Fatalf("expected attempts to be reset, got %d", v.Attempts)
+	}
+
+	repo.DeleteVerification(ctx, v.ID)
+	if attempts := repo.IncrementVerificationAttempts(ctx, v.ID); attempts != 0 {
+		t.Fatalf("expected 0 for deleted verification, got %d", attempts)
+	}
+}
+
 func TestUpsertForEntityCreatesUser(t *testing.T) {
 	repo, cleanup := setup(t)
 	defer cleanup()
@@ -274,3 +330,107 @@ func TestGetByIdList(t *testing.T) {
 		t.Fatal("expected user to have ID")
 	}
 }
+
+func TestConsumeRefreshToken(t *testing.T) {
+	repo, cleanup := setup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	user := repo.CreateForEntity(ctx, goauthlib.AuthorizationEntity{
+		Type:  goauthlib.EntityTypeEmail,
+		Value: "refresh@test.com",
+	})
+
+	repo.CreateRefreshToken(ctx, goauthlib.RefreshToken{
+		UserID:    user.ID,
+		Hash:      "refresh-hash",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	})
+
+	token := repo.ConsumeRefreshToken(ctx, "refresh-hash")
+	if token == nil {
+		t.Fatal("expected refresh token but got nil")
+	}
+	if token.UserID != user.ID {
+		t.Fatalf("unexpected user id: %s", token.UserID)
+	}
+
+	if repo.ConsumeRefreshToken(ctx, "refresh-hash") != nil {
+		t.Fatal("expected refresh token to be consumed only once")
+	}
+}
+
+func TestIncrementTokenVersion(t *testing.T) {
+	repo, cleanup := setup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	user := repo.CreateForEntity(ctx, goauthlib.AuthorizationEntity{
+		Type:  goauthlib.EntityTypeEmail,
+		Value: "version@test.com",
+	})
+
+	version, err := repo.GetTokenVersion(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 0 {
+		t.Fatalf("expected initial token version 0, got %d", version)
+	}
+
+	if repo.IncrementTokenVersion(ctx, user.ID) != 1 {
+		t.Fatal("expected token version 1 after increment")
+	}
+
+	// Save must not reset token version
+	user.Info["name"] = "John"
+	repo.Save(ctx, user)
+
+	version, err = repo.GetTokenVersion(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 {
+		t.Fatalf("expected token version 1, got %d", version)
+	}
+	if got := repo.GetById(ctx, user.ID); got.TokenVersion != 1 {
+		t.Fatalf("expected user token version 1, got %d", got.TokenVersion)
+	}
+}
+
+func TestGrantAndRevokeRole(t *testing.T) {
+	repo, cleanup := setup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo.SetRolePermissions(map[string][]string{"moderator": {"posts:delete"}})
+	user := repo.CreateForEntity(ctx, goauthlib.AuthorizationEntity{
+		Type:  goauthlib.EntityTypeEmail,
+		Value: "roles@test.com",
+	})
+
+	if !repo.GrantRole(ctx, user.ID, "moderator") {
+		t.Fatal("expected role to be granted")
+	}
+	if repo.GrantRole(ctx, user.ID, "moderator") {
+		t.Fatal("expected second grant to change nothing")
+	}
+
+	loaded := repo.GetById(ctx, user.ID)
+	if !loaded.HasRole("moderator") || !loaded.HasPermission("posts:delete") {
+		t.Fatalf("expected moderator role and permissions, got %v %v", loaded.Roles, loaded.Permissions)
+	}
+
+	other := NewRepository(repo.Client, "other_service")
+	other.EnsureService(ctx, user.ID)
+	if roles := other.GetById(ctx, user.ID).Roles; len(roles) != 0 {
+		t.Fatalf("expected no roles in another service, got %v", roles)
+	}
+
+	if !repo.RevokeRole(ctx, user.ID, "moderator") {
+		t.Fatal("expected role to be revoked")
+	}
+	if repo.GetById(ctx, user.ID).HasRole("moderator") {
+		t.Fatal("expected role to be removed")
+	}
+}
@@ -0,0 +1,49 @@
+package mongo
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"time"
+)
+
+// RevocationStore keeps revoked token ids. Documents are removed by TTL index when tokens expire.
+type RevocationStore struct {
+	Client *mongo.Client
+}
+
+func NewRevocationStore(client *mongo.Client) *RevocationStore {
+	return &RevocationStore{Client: client}
+}
+
+func (store *RevocationStore) collection() *mongo.Collection {
+	return store.Client.Database(dbName).Collection(revokedTokenCollection)
+}
+
+// EnsureIndexes creates TTL index on expiration date. Call it once on startup.
+func (store *RevocationStore) EnsureIndexes(ctx context.Context) error {
+	_, err := store.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (store *RevocationStore) Revoke(ctx context.Context, tokenId string, expiresAt time.Time) error {
+	set := bson.M{"revoked_at": time.Now()}
+	// Tokens without expiration are kept forever, TTL index skips documents without the field.
+	if !expiresAt.IsZero() {
+		set["expires_at"] = expiresAt
+	}
+	_, err := store.collection().UpdateOne(ctx, bson.M{"_id": tokenId}, bson.M{"$set": set}, options.UpdateOne().SetUpsert(true))
+	return err
+}
+
+func (store *RevocationStore) IsRevoked(ctx context.Context, tokenId string) (bool, error) {
+	count, err := store.collection().CountDocuments(ctx, bson.M{"_id": tokenId}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
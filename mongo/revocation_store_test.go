@@ -0,0 +1,37 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRevocationStore(t *testing.T) {
+	repo, cleanup := setup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := NewRevocationStore(repo.Client)
+	if err := store.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	revoked, err := store.IsRevoked(ctx, "token-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked {
+		t.Fatal("expected token not to be revoked")
+	}
+
+	if err := store.Revoke(ctx, "token-id", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	revoked, err = store.IsRevoked(ctx, "token-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Fatal("expected token to be revoked")
+	}
+}
@@ -0,0 +1,100 @@
+package mongo
+
+import (
+	"context"
+	"github.com/techpro-studio/goauthlib"
+	"github.com/techpro-studio/gomongo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type SessionRepository struct {
+	Client  *mongo.Client
+	service string
+}
+
+func NewSessionRepository(client *mongo.Client, service string) *SessionRepository {
+	return &SessionRepository{Client: client, service: service}
+}
+
+func (repo *SessionRepository) collection() *mongo.Collection {
+	return repo.Client.Database(dbName).Collection(sessionCollection)
+}
+
+func (repo *SessionRepository) CreateSession(ctx context.Context, session goauthlib.Session) *goauthlib.Session {
+	mongoSession := mongoSession{
+		ID:         bson.NewObjectID(),
+		UserID:     *gomongo.StrToObjId(&session.UserID),
+		Device:     session.Device,
+		IP:         session.IP,
+		UserAgent:  session.UserAgent,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		Service:    repo.service,
+	}
+	_, err := repo.collection().InsertOne(ctx, mongoSession)
+	if err != nil {
+		panic(err)
+	}
+	return toDomainSession(&mongoSession)
+}
+
+func (repo *SessionRepository) GetSession(ctx context.Context, id string) *goauthlib.Session {
+	objId, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil
+	}
+	var mongoSession mongoSession
+	err = repo.collection().FindOne(ctx, bson.M{"_id": objId, "service": repo.service}).Decode(&mongoSession)
+	if err != nil {
+		if err.Error() != notFoundDocumentError {
+			panic(err)
+		}
+		return nil
+	}
+	return toDomainSession(&mongoSession)
+}
+
+func (repo *SessionRepository) TouchSession(ctx context.Context, id string, lastSeenAt int64) {
+	_, err := repo.collection().UpdateOne(ctx, bson.M{"_id": *gomongo.StrToObjId(&id)}, bson.M{"$max": bson.M{"last_seen_at": lastSeenAt}})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ListSessions returns active sessions, recently used first.
+func (repo *SessionRepository) ListSessions(ctx context.Context, userId string) []*goauthlib.Session {
+	query := bson.M{"user_id": *gomongo.StrToObjId(&userId), "service": repo.service, "revoked": false}
+	cursor, err := repo.collection().Find(ctx, query, options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}}))
+	if err != nil {
+		panic(err)
+	}
+	var sessions []*mongoSession
+	err = cursor.All(ctx, &sessions)
+	if err != nil {
+		panic(err)
+	}
+	return gomongo.SliceMap(sessions, toDomainSession)
+}
+
+func (repo *SessionRepository) RevokeSession(ctx context.Context, userId, id string) bool {
+	objId, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return false
+	}
+	query := bson.M{"_id": objId, "user_id": *gomongo.StrToObjId(&userId), "service": repo.service, "revoked": false}
+	result, err := repo.collection().UpdateOne(ctx, query, bson.M{"$set": bson.M{"revoked": true}})
+	if err != nil {
+		panic(err)
+	}
+	return result.ModifiedCount > 0
+}
+
+func (repo *SessionRepository) RevokeAllSessions(ctx context.Context, userId string) {
+	query := bson.M{"user_id": *gomongo.StrToObjId(&userId), "service": repo.service, "revoked": false}
+	_, err := repo.collection().UpdateMany(ctx, query, bson.M{"$set": bson.M{"revoked": true}})
+	if err != nil {
+		panic(err)
+	}
+}
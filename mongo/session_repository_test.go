@@ -0,0 +1,44 @@
+package mongo
+
+import (
+	"context"
+	"github.com/techpro-studio/goauthlib"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"testing"
+)
+
+func TestSessionRepository(t *testing.T) {
+	repo, cleanup := setup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	sessions := NewSessionRepository(repo.Client, service)
+	userId := bson.NewObjectID().Hex()
+
+	first := sessions.CreateSession(ctx, goauthlib.Session{UserID: userId, Device: "phone", CreatedAt: 1, LastSeenAt: 1})
+	second := sessions.CreateSession(ctx, goauthlib.Session{UserID: userId, Device: "laptop", CreatedAt: 2, LastSeenAt: 2})
+
+	sessions.TouchSession(ctx, first.ID, 10)
+	list := sessions.ListSessions(ctx, userId)
+	if len(list) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(list))
+	}
+	if list[0].ID != first.ID || list[0].LastSeenAt != 10 {
+		t.Fatalf("expected recently used session first, got %+v", list[0])
+	}
+
+	if sessions.RevokeSession(ctx, bson.NewObjectID().Hex(), second.ID) {
+		t.Fatal("expected session of another user not to be revoked")
+	}
+	if !sessions.RevokeSession(ctx, userId, second.ID) {
+		t.Fatal("expected session to be revoked")
+	}
+	if got := sessions.GetSession(ctx, second.ID); got == nil || !got.Revoked {
+		t.Fatalf("expected revoked session, got %+v", got)
+	}
+
+	sessions.RevokeAllSessions(ctx, userId)
+	if len(sessions.ListSessions(ctx, userId)) != 0 {
+		t.Fatal("expected no active sessions")
+	}
+}
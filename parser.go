@@ -12,9 +12,10 @@ func MakeAuthorizationEntityVMap() validator.VMap {
 	}
 }
 
+// MakeCodeVMap requires only a code, its length and alphabet follow the CodeSpec and are checked by GetCodeForSpec.
 func MakeCodeVMap() validator.VMap {
 	return validator.VMap{
-		"code": validator.RequiredStringValidators("code", validator.StringLengthValidator(5, "code")),
+		"code": validator.RequiredStringValidators("code"),
 	}
 }
 
@@ -33,6 +34,46 @@ func MakeTempTokenVMap() validator.VMap {
 	}
 }
 
+func MakeMagicLinkVMap() validator.VMap {
+	return validator.VMap{
+		"token": validator.RequiredStringValidators("token"),
+	}
+}
+
+func MakeRefreshTokenVMap() validator.VMap {
+	return validator.VMap{
+		"refresh_token": validator.RequiredStringValidators("refresh_token"),
+	}
+}
+
+func MakeSessionVMap() validator.VMap {
+	return validator.VMap{
+		"id": validator.RequiredStringValidators("id"),
+	}
+}
+
+func MakeImpersonationVMap() validator.VMap {
+	return validator.VMap{
+		"user_id": validator.RequiredStringValidators("user_id"),
+	}
+}
+
+func MakeTokenExchangeVMap() validator.VMap {
+	return validator.VMap{
+		"grant_type":         validator.RequiredStringValidators("grant_type", validator.StringContainsValidator("grant_type", []string{TokenExchangeGrantType})),
+		"subject_token":      validator.RequiredStringValidators("subject_token"),
+		"subject_token_type": validator.RequiredStringValidators("subject_token_type", validator.StringContainsValidator("subject_token_type", []string{AccessTokenType, JWTTokenType})),
+		"audience":           validator.RequiredStringValidators("audience"),
+	}
+}
+
+func MakeRoleVMap() validator.VMap {
+	return validator.VMap{
+		"user_id": validator.RequiredStringValidators("user_id"),
+		"role":    validator.RequiredStringValidators("role"),
+	}
+}
+
 type SocialProviderPayload struct {
 	Provider    string
 	Payload     string
@@ -61,11 +102,95 @@ func GetSocialProviderPayloadInfo(body map[string]interface{}) (*SocialProviderP
 }
 
 func GetCode(body map[string]interface{}) (string, error) {
+	return GetCodeForSpec(body, DefaultCodeSpec())
+}
+
+// GetCodeForSpec validates the code against the spec of codes sent to the entity, see UseCase.CodeSpec.
+func GetCodeForSpec(body map[string]interface{}, spec CodeSpec) (string, error) {
 	validated, err := validator.ValidateBody(body, MakeCodeVMap())
 	if err != nil {
 		return "", err
 	}
-	return validated["code"].(string), nil
+	code := spec.Normalize(validated["code"].(string))
+	if !spec.Matches(code) {
+		return "", invalidCodeFormat
+	}
+	return code, nil
+}
+
+// GetMagicLinkRequest returns token of the link with the device token or confirmation code of links
+// bound to the device.
+func GetMagicLinkRequest(body map[string]interface{}) (*MagicLinkRequest, error) {
+	validated, err := validator.ValidateBody(body, MakeMagicLinkVMap())
+	if err != nil {
+		return nil, err
+	}
+	request := &MagicLinkRequest{Token: validated["token"].(string)}
+	request.DeviceToken, _ = body["device_token"].(string)
+	request.ConfirmationCode, _ = body["confirmation_code"].(string)
+	return request, nil
+}
+
+func GetRefreshToken(body map[string]interface{}) (string, error) {
+	validated, err := validator.ValidateBody(body, MakeRefreshTokenVMap())
+	if err != nil {
+		return "", err
+	}
+	return validated["refresh_token"].(string), nil
+}
+
+func GetSessionId(body map[string]interface{}) (string, error) {
+	validated, err := validator.ValidateBody(body, MakeSessionVMap())
+	if err != nil {
+		return "", err
+	}
+	return validated["id"].(string), nil
+}
+
+// GetImpersonationRequest reads user_id and optional read_only, which is true by default, and reason.
+func GetImpersonationRequest(body map[string]interface{}) (*ImpersonationRequest, error) {
+	validated, err := validator.ValidateBody(body, MakeImpersonationVMap())
+	if err != nil {
+		return nil, err
+	}
+	request := ImpersonationRequest{UserID: validated["user_id"].(string), ReadOnly: true}
+	if readOnly, ok := body["read_only"].(bool); ok {
+		request.ReadOnly = readOnly
+	}
+	request.Reason, _ = body["reason"].(string)
+	return &request, nil
+}
+
+func GetTokenExchangeRequest(body map[string]interface{}) (*TokenExchangeRequest, error) {
+	validated, err := validator.ValidateBody(body, MakeTokenExchangeVMap())
+	if err != nil {
+		return nil, err
+	}
+	if requested, ok := body["requested_token_type"].(string); ok && requested != "" && requested != AccessTokenType {
+		return nil, gohttplib.NewServerError(400, "INVALID_REQUEST", "Only access tokens can be requested", "requested_token_type", nil)
+	}
+	scope, _ := body["scope"].(string)
+	return &TokenExchangeRequest{
+		SubjectToken: validated["subject_token"].(string),
+		Audience:     validated["audience"].(string),
+		Scope:        parseScope(scope),
+	}, nil
+}
+
+func GetRoleRequest(body map[string]interface{}) (userId string, role string, err error) {
+	validated, err := validator.ValidateBody(body, MakeRoleVMap())
+	if err != nil {
+		return "", "", err
+	}
+	return validated["user_id"].(string), validated["role"].(string), nil
+}
+
+func GetTokenFromBody(body map[string]interface{}) (string, error) {
+	validated, err := validator.ValidateBody(body, MakeTempTokenVMap())
+	if err != nil {
+		return "", err
+	}
+	return validated["token"].(string), nil
 }
 
 func GetAuthorizationEntityFromBody(body map[string]interface{}) (*AuthorizationEntity, error) {
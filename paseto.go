@@ -0,0 +1,153 @@
+package goauthlib
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"strings"
+	"time"
+)
+
+const pasetoV4PublicHeader = "v4.public."
+
+// pasetoTimeClaims are numeric dates in JWT, but RFC 3339 strings in PASETO.
+var pasetoTimeClaims = []string{"exp", "iat", "nbf"}
+
+// PasetoV4Public is a TokenFormat of PASETO v4.public tokens. Algorithm is fixed to Ed25519 by the version,
+// so there is no algorithm header to confuse.
+type PasetoV4Public struct {
+	signer    Signer
+	publicKey ed25519.PublicKey
+}
+
+func NewPasetoV4Public(privateKey ed25519.PrivateKey) *PasetoV4Public {
+	return &PasetoV4Public{
+		signer:    NewLocalSigner(jwt.SigningMethodEdDSA, privateKey),
+		publicKey: privateKey.Public().(ed25519.PublicKey),
+	}
+}
+
+// NewPasetoV4PublicWithSigner uses external signer. Its public key has to be Ed25519.
+func NewPasetoV4PublicWithSigner(signer Signer) (*PasetoV4Public, error) {
+	publicKey, ok := signer.PublicKey().(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("v4.public requires Ed25519 key, got %T", signer.PublicKey())
+	}
+	return &PasetoV4Public{signer: signer, publicKey: publicKey}, nil
+}
+
+// NewPasetoV4PublicVerifier can only parse tokens.
+func NewPasetoV4PublicVerifier(publicKey ed25519.PublicKey) *PasetoV4Public {
+	return &PasetoV4Public{publicKey: publicKey}
+}
+
+func (p *PasetoV4Public) PublicKey() ed25519.PublicKey {
+	return p.publicKey
+}
+
+func (p *PasetoV4Public) Sign(ctx context.Context, claims any) (string, error) {
+	if p.signer == nil {
+		return "", errors.New("paseto format has no signer")
+	}
+	message, err := pasetoMessage(claims)
+	if err != nil {
+		return "", err
+	}
+	signature, err := p.signer.Sign(ctx, preAuthEncode([]byte(pasetoV4PublicHeader), message, nil, nil))
+	if err != nil {
+		return "", err
+	}
+	return pasetoV4PublicHeader + base64.RawURLEncoding.EncodeToString(append(message, signature...)), nil
+}
+
+func (p *PasetoV4Public) Parse(token string) (map[string]any, error) {
+	message, err := p.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	var claims map[string]any
+	err = json.Unmarshal(message, &claims)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range pasetoTimeClaims {
+		value, ok := claims[key]
+		if !ok {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s claim", key)
+		}
+		parsed, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s claim: %w", key, err)
+		}
+		claims[key] = float64(parsed.Unix())
+	}
+	return claims, nil
+}
+
+// verify checks the signature and returns the signed message.
+func (p *PasetoV4Public) verify(token string) ([]byte, error) {
+	if !strings.HasPrefix(token, pasetoV4PublicHeader) {
+		return nil, errors.New("token is not v4.public")
+	}
+	parts := strings.Split(token[len(pasetoV4PublicHeader):], ".")
+	if len(parts) > 2 {
+		return nil, errors.New("token is malformed")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	var footer []byte
+	if len(parts) == 2 {
+		footer, err = base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(payload) < ed25519.SignatureSize {
+		return nil, errors.New("token is malformed")
+	}
+	message := payload[:len(payload)-ed25519.SignatureSize]
+	signature := payload[len(payload)-ed25519.SignatureSize:]
+	if !ed25519.Verify(p.publicKey, preAuthEncode([]byte(pasetoV4PublicHeader), message, footer, nil), signature) {
+		return nil, errors.New("token signature is invalid")
+	}
+	return message, nil
+}
+
+func pasetoMessage(claims any) ([]byte, error) {
+	bytes, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	var converted map[string]any
+	err = json.Unmarshal(bytes, &converted)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range pasetoTimeClaims {
+		if value, ok := converted[key].(float64); ok {
+			converted[key] = time.Unix(int64(value), 0).UTC().Format(time.RFC3339)
+		}
+	}
+	return json.Marshal(converted)
+}
+
+// preAuthEncode is PAE from PASETO specification.
+func preAuthEncode(pieces ...[]byte) []byte {
+	output := binary.LittleEndian.AppendUint64(nil, uint64(len(pieces)))
+	for _, piece := range pieces {
+		output = binary.LittleEndian.AppendUint64(output, uint64(len(piece))&(1<<63-1))
+		output = append(output, piece...)
+	}
+	return output
+}
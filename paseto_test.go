@@ -0,0 +1,127 @@
+package goauthlib
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TestPasetoV4PublicVector checks the implementation against 4-S-1 vector of PASETO specification.
+func TestPasetoV4PublicVector(t *testing.T) {
+	secret, _ := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	message := []byte(`{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`)
+	expected := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+
+	format := NewPasetoV4Public(ed25519.PrivateKey(secret))
+	signature := ed25519.Sign(ed25519.PrivateKey(secret), preAuthEncode([]byte(pasetoV4PublicHeader), message, nil, nil))
+	token := pasetoV4PublicHeader + base64.RawURLEncoding.EncodeToString(append(message, signature...))
+	if token != expected {
+		t.Fatalf("unexpected token:\n%s\nwant:\n%s", token, expected)
+	}
+
+	verified, err := format.verify(expected)
+	if err != nil {
+		t.Fatalf("failed to verify vector: %v", err)
+	}
+	if string(verified) != string(message) {
+		t.Errorf("unexpected message: %s", verified)
+	}
+}
+
+func TestPasetoConfig(t *testing.T) {
+	ctx := context.Background()
+	user := User{ID: bson.NewObjectID().Hex()}
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key pair: %v", err)
+	}
+
+	cfg := NewJWTConfigWithFormat(NewPasetoV4Public(privateKey), "test-blinder")
+	cfg.SetAccessTokenTTL(time.Hour)
+	cfg.SetAudience("service-a")
+
+	token, err := cfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if !strings.HasPrefix(token, "v4.public.") {
+		t.Fatalf("expected PASETO token, got %s", token)
+	}
+	message, err := cfg.Format().(*PasetoV4Public).verify(token)
+	if err != nil {
+		t.Fatalf("failed to verify token: %v", err)
+	}
+	if !strings.Contains(string(message), `"exp":"`) {
+		t.Errorf("expected RFC 3339 exp claim, got %s", message)
+	}
+
+	verifier := NewJWTConfigWithFormat(NewPasetoV4PublicVerifier(privateKey.Public().(ed25519.PublicKey)), "test-blinder")
+	verifier.SetAudience("service-a")
+	info, err := verifier.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if info.User.ID != user.ID || info.ExpiresAt.IsZero() {
+		t.Errorf("unexpected token info: %+v", info)
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "JWT "+token)
+	userId, err := verifier.SafeExtractUserIdFromHeader(header)
+	if err != nil || userId != user.ID {
+		t.Errorf("SafeExtractUserIdFromHeader failed: %v, %s", err, userId)
+	}
+
+	if _, err := verifier.Format().Sign(ctx, map[string]any{}); err == nil {
+		t.Error("expected verifier not to sign")
+	}
+
+	anotherService := verifier.Copy()
+	anotherService.SetAudience("service-b")
+	if _, err := anotherService.GetValidUserFromToken(token); err == nil {
+		t.Error("expected token of another audience to be rejected")
+	}
+
+	// The last character may only carry padding bits, so one inside the signature is changed.
+	replaced := byte('A')
+	if token[len(token)-10] == replaced {
+		replaced = 'B'
+	}
+	tampered := token[:len(token)-10] + string(replaced) + token[len(token)-9:]
+	if _, err := verifier.GetValidUserFromToken(tampered); err == nil {
+		t.Error("expected tampered token to be rejected")
+	}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodEdDSA, privateKey, privateKey.Public(), "test-blinder")
+	jwtToken, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if _, err := verifier.GetValidUserFromToken(jwtToken); err == nil {
+		t.Error("expected JWT to be rejected by PASETO config")
+	}
+
+	expired, err := cfg.Format().Sign(ctx, tokenClaims{
+		Hash: GenerateTokenHash(user, "test-blinder"),
+		User: &user,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "auth",
+			Audience:  jwt.ClaimStrings{"service-a"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if _, err := verifier.GetValidUserFromToken(expired); err == nil {
+		t.Error("expected expired token to be rejected")
+	}
+}
@@ -0,0 +1,26 @@
+package goauthlib
+
+import (
+	"crypto/rand"
+	"crypto/sha3"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const refreshTokenLength = 32
+
+// GenerateRefreshToken returns an opaque refresh token for a client and its hash for storage.
+func GenerateRefreshToken() (string, string, error) {
+	bytes := make([]byte, refreshTokenLength)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(bytes)
+	return token, HashRefreshToken(token), nil
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha3.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,142 @@
+package goauthlib
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type testRefreshRepository struct {
+	testUserRepository
+	refreshTokens map[string]*RefreshToken
+}
+
+func (r *testRefreshRepository) CreateRefreshToken(ctx context.Context, token RefreshToken) {
+	token.ID = bson.NewObjectID().Hex()
+	r.refreshTokens[token.Hash] = &token
+}
+
+func (r *testRefreshRepository) ConsumeRefreshToken(ctx context.Context, hash string) *RefreshToken {
+	token := r.refreshTokens[hash]
+	delete(r.refreshTokens, hash)
+	return token
+}
+
+func (r *testRefreshRepository) DeleteRefreshTokensForUser(ctx context.Context, userId string) {
+	for hash, token := range r.refreshTokens {
+		if token.UserID == userId {
+			delete(r.refreshTokens, hash)
+		}
+	}
+}
+
+func newRefreshUseCase() (*DefaultUseCase, *testRefreshRepository, *testSessionRepository, *User) {
+	user := &User{ID: bson.NewObjectID().Hex(), Info: map[string]any{}}
+	repository := &testRefreshRepository{testUserRepository: testUserRepository{users: map[string]*User{user.ID: user}}, refreshTokens: map[string]*RefreshToken{}}
+	sessions := &testSessionRepository{sessions: map[string]*Session{}}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetAccessTokenTTL(time.Hour)
+	jwtCfg.SetRefreshTokenTTL(24 * time.Hour)
+	jwtCfg.SetSessionRepository(sessions)
+	return NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback()), repository, sessions, user
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rotation", func(t *testing.T) {
+		useCase, _, _, user := newRefreshUseCase()
+		login, err := useCase.generateResponseFor(ctx, user, nil, AuthMethodEmailCode)
+		if err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+		refreshed, err := useCase.Refresh(ctx, login.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		if refreshed.RefreshToken == "" || refreshed.RefreshToken == login.RefreshToken {
+			t.Fatal("expected a new refresh token")
+		}
+		loginInfo, _ := useCase.jwtConfig.ValidateToken(ctx, login.Token)
+		info, err := useCase.jwtConfig.ValidateToken(ctx, refreshed.Token)
+		if err != nil {
+			t.Fatalf("refreshed token is invalid: %v", err)
+		}
+		if info.SessionID == "" || info.SessionID != loginInfo.SessionID {
+			t.Errorf("expected session %q to be kept, got %q", loginInfo.SessionID, info.SessionID)
+		}
+		if len(info.AuthMethods) != 1 || info.AuthMethods[0] != AuthMethodEmailCode || !info.AuthTime.Equal(loginInfo.AuthTime) {
+			t.Errorf("expected authentication of the login to be kept, got %v %v", info.AuthMethods, info.AuthTime)
+		}
+		if _, err := useCase.Refresh(ctx, refreshed.RefreshToken); err != nil {
+			t.Errorf("expected the new refresh token to work: %v", err)
+		}
+	})
+
+	t.Run("single use", func(t *testing.T) {
+		useCase, _, _, user := newRefreshUseCase()
+		login, err := useCase.generateResponseFor(ctx, user, nil, AuthMethodEmailCode)
+		if err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+		if _, err := useCase.Refresh(ctx, login.RefreshToken); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		if _, err := useCase.Refresh(ctx, login.RefreshToken); err == nil || err.Error() != invalidRefreshToken.Error() {
+			t.Errorf("expected INVALID_REFRESH_TOKEN on replay, got %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		useCase, repository, _, user := newRefreshUseCase()
+		login, err := useCase.generateResponseFor(ctx, user, nil, AuthMethodEmailCode)
+		if err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+		repository.refreshTokens[HashRefreshToken(login.RefreshToken)].ExpiresAt = time.Now().Add(-time.Minute).Unix()
+		if _, err := useCase.Refresh(ctx, login.RefreshToken); err == nil || err.Error() != invalidRefreshToken.Error() {
+			t.Errorf("expected INVALID_REFRESH_TOKEN for expired token, got %v", err)
+		}
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		useCase, _, sessions, user := newRefreshUseCase()
+		login, err := useCase.generateResponseFor(ctx, user, nil, AuthMethodEmailCode)
+		if err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+		sessions.RevokeAllSessions(ctx, user.ID)
+		if _, err := useCase.Refresh(ctx, login.RefreshToken); err == nil || err.Error() != sessionRevoked.Error() {
+			t.Errorf("expected SESSION_REVOKED, got %v", err)
+		}
+	})
+
+	t.Run("dpop key mismatch", func(t *testing.T) {
+		useCase, _, _, user := newRefreshUseCase()
+		bound := WithDPoPThumbprint(ctx, "client-key")
+		login, err := useCase.generateResponseFor(bound, user, nil, AuthMethodEmailCode)
+		if err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+		if _, err := useCase.Refresh(WithDPoPThumbprint(ctx, "another-key"), login.RefreshToken); err == nil || err.Error() != invalidRefreshToken.Error() {
+			t.Errorf("expected INVALID_REFRESH_TOKEN for another key, got %v", err)
+		}
+
+		login, err = useCase.generateResponseFor(bound, user, nil, AuthMethodEmailCode)
+		if err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+		refreshed, err := useCase.Refresh(bound, login.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh with the bound key failed: %v", err)
+		}
+		info, err := useCase.jwtConfig.ParseToken(refreshed.Token)
+		if err != nil || info.KeyThumbprint != "client-key" {
+			t.Errorf("expected refreshed token to stay bound, got %v, %v", info, err)
+		}
+	})
+}
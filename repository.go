@@ -16,11 +16,39 @@ type Repository interface {
 	Save(ctx context.Context, model *User)
 	GetVerificationForEntity(ctx context.Context, entity AuthorizationEntity) *Verification
 	GetServiceActionVerification(ctx context.Context, action string) *Verification
-	CreateServiceActionVerification(ctx context.Context, action, verificationCode string)
-	CreateVerificationForEntity(ctx context.Context, entity AuthorizationEntity, verificationCode string)
+	// CreateServiceActionVerification and CreateVerificationForEntity store HMAC of the code, never the code itself.
+	CreateServiceActionVerification(ctx context.Context, action, codeHash string)
+	CreateVerificationForEntity(ctx context.Context, entity AuthorizationEntity, codeHash string)
+	// GetActionVerificationForEntity and CreateActionVerificationForEntity keep verifications of an action of the entity,
+	// e.g. MagicLinkAction, apart from the code of the entity, so they don't replace each other.
+	GetActionVerificationForEntity(ctx context.Context, entity AuthorizationEntity, action string) *Verification
+	CreateActionVerificationForEntity(ctx context.Context, entity AuthorizationEntity, action, codeHash string)
 	DeleteVerification(ctx context.Context, id string)
+	// IncrementVerificationAttempts atomically counts a check of the code and returns the new number of attempts.
+	// It returns 0 when the verification doesn't exist.
+	IncrementVerificationAttempts(ctx context.Context, id string) int
 	GetById(ctx context.Context, id string) *User
 	GetByIdList(ctx context.Context, id []string) []*User
 	SaveOAuthData(ctx context.Context, result *oauth.ProviderResult)
 	GetTokensFor(ctx context.Context, entity *AuthorizationEntity) (*oauth.Tokens, error)
+	CreateRefreshToken(ctx context.Context, token RefreshToken)
+	ConsumeRefreshToken(ctx context.Context, hash string) *RefreshToken
+	DeleteRefreshTokensForUser(ctx context.Context, userId string)
+	TokenVersionSource
+	IncrementTokenVersion(ctx context.Context, userId string) int64
+	GrantRole(ctx context.Context, userId string, role string) bool
+	RevokeRole(ctx context.Context, userId string, role string) bool
+}
+
+type TokenVersionSource interface {
+	GetTokenVersion(ctx context.Context, userId string) (int64, error)
+}
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session Session) *Session
+	GetSession(ctx context.Context, id string) *Session
+	TouchSession(ctx context.Context, id string, lastSeenAt int64)
+	ListSessions(ctx context.Context, userId string) []*Session
+	RevokeSession(ctx context.Context, userId, id string) bool
+	RevokeAllSessions(ctx context.Context, userId string)
 }
@@ -0,0 +1,70 @@
+package goauthlib
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RevocationStore keeps ids of revoked tokens until the tokens expire.
+// Zero expiresAt means the token never expires and the id is kept forever.
+type RevocationStore interface {
+	Revoke(ctx context.Context, tokenId string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenId string) (bool, error)
+}
+
+func newTokenId() (string, error) {
+	bytes := make([]byte, 16)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func checkRevocation(ctx context.Context, revocations RevocationStore, info *TokenInfo) error {
+	if info.ID == "" {
+		return errors.New("token has no id")
+	}
+	revoked, err := revocations.IsRevoked(ctx, info.ID)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return tokenRevoked
+	}
+	return nil
+}
+
+// MemoryRevocationStore is a RevocationStore for a single instance deployments and tests.
+type MemoryRevocationStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{revoked: map[string]time.Time{}}
+}
+
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, tokenId string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, exp := range s.revoked {
+		if !exp.IsZero() && exp.Before(now) {
+			delete(s.revoked, id)
+		}
+	}
+	s.revoked[tokenId] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, tokenId string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.revoked[tokenId]
+	return ok, nil
+}
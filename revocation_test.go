@@ -0,0 +1,259 @@
+package goauthlib
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/techpro-studio/goauthlib/oauth"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestRevokeToken(t *testing.T) {
+	ctx := context.Background()
+	user := User{ID: bson.NewObjectID().Hex()}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetAccessTokenTTL(time.Hour)
+	jwtCfg.SetRevocationStore(NewMemoryRevocationStore())
+
+	token, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	another, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	info, err := jwtCfg.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if info.ID == "" {
+		t.Fatal("expected jti claim")
+	}
+	if info.ExpiresAt.IsZero() {
+		t.Fatal("expected expiration time")
+	}
+
+	if err := jwtCfg.RevokeToken(ctx, info); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if _, err := jwtCfg.GetValidUserFromToken(token); err == nil {
+		t.Error("expected revoked token to be rejected")
+	}
+	if _, err := jwtCfg.GetValidUserFromToken(another); err != nil {
+		t.Errorf("expected other token to stay valid: %v", err)
+	}
+}
+
+func TestMemoryRevocationStoreDropsExpired(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRevocationStore()
+
+	_ = store.Revoke(ctx, "expired", time.Now().Add(-time.Minute))
+	_ = store.Revoke(ctx, "forever", time.Time{})
+	_ = store.Revoke(ctx, "fresh", time.Now().Add(time.Minute))
+
+	if revoked, _ := store.IsRevoked(ctx, "expired"); revoked {
+		t.Error("expected expired token id to be dropped")
+	}
+	if revoked, _ := store.IsRevoked(ctx, "forever"); !revoked {
+		t.Error("expected token without expiration to stay revoked")
+	}
+	if revoked, _ := store.IsRevoked(ctx, "fresh"); !revoked {
+		t.Error("expected token to be revoked")
+	}
+}
+
+type testTokenVersionSource map[string]int64
+
+func (s testTokenVersionSource) GetTokenVersion(ctx context.Context, userId string) (int64, error) {
+	return s[userId], nil
+}
+
+func TestTokenVersion(t *testing.T) {
+	ctx := context.Background()
+	user := User{ID: bson.NewObjectID().Hex(), TokenVersion: 3}
+	versions := testTokenVersionSource{user.ID: 3}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetTokenVersionSource(versions)
+
+	token, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	info, err := jwtCfg.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if info.User.TokenVersion != 3 {
+		t.Errorf("expected token version 3, got %d", info.User.TokenVersion)
+	}
+
+	versions[user.ID] = 4
+	if _, err := jwtCfg.ValidateToken(ctx, token); err == nil {
+		t.Error("expected token with outdated version to be rejected")
+	}
+
+	other := User{ID: bson.NewObjectID().Hex()}
+	otherToken, err := jwtCfg.GenerateTokenFromModel(other)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if _, err := jwtCfg.ValidateToken(ctx, otherToken); err != nil {
+		t.Errorf("expected token of another user to stay valid: %v", err)
+	}
+}
+
+type testDeleteRepository struct {
+	testRefreshRepository
+	removed []string
+}
+
+func (r *testDeleteRepository) RemoveService(ctx context.Context, id string, softDeleteIfNoServices bool, callback func(ctx context.Context, userId string) error) {
+	r.removed = append(r.removed, id)
+	_ = callback(ctx, id)
+}
+
+func TestForceDeleteRevokesCallerToken(t *testing.T) {
+	ctx := context.Background()
+	user := &User{ID: bson.NewObjectID().Hex()}
+	repository := &testDeleteRepository{testRefreshRepository: testRefreshRepository{testUserRepository: testUserRepository{users: map[string]*User{user.ID: user}}, refreshTokens: map[string]*RefreshToken{}}}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetAccessTokenTTL(time.Hour)
+	jwtCfg.SetRefreshTokenTTL(time.Hour)
+	jwtCfg.SetRevocationStore(NewMemoryRevocationStore())
+	useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+
+	login, err := useCase.generateResponseFor(ctx, user, nil, AuthMethodEmailCode)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	other, err := jwtCfg.GenerateTokenFromModel(User{ID: bson.NewObjectID().Hex()})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	info, err := jwtCfg.ValidateToken(ctx, login.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+
+	if err := useCase.ForceDelete(WithTokenInfo(ctx, info), *user); err != nil {
+		t.Fatalf("ForceDelete failed: %v", err)
+	}
+	if len(repository.removed) != 1 || repository.removed[0] != user.ID {
+		t.Errorf("expected service to be removed from the user, got %v", repository.removed)
+	}
+	if _, err := jwtCfg.ValidateToken(ctx, login.Token); err == nil || err.Error() != tokenRevoked.Error() {
+		t.Errorf("expected TOKEN_REVOKED for the caller token, got %v", err)
+	}
+	if _, err := useCase.Refresh(ctx, login.RefreshToken); err == nil {
+		t.Error("expected refresh token of the deleted user to be rejected")
+	}
+	if _, err := jwtCfg.ValidateToken(ctx, other); err != nil {
+		t.Errorf("expected token of another user to stay valid: %v", err)
+	}
+}
+
+type testVersionRepository struct {
+	testRefreshRepository
+	versions map[string]int64
+}
+
+func (r *testVersionRepository) GetTokenVersion(ctx context.Context, userId string) (int64, error) {
+	return r.versions[userId], nil
+}
+
+func (r *testVersionRepository) IncrementTokenVersion(ctx context.Context, userId string) int64 {
+	r.versions[userId]++
+	return r.versions[userId]
+}
+
+func (r *testVersionRepository) GetForSocial(ctx context.Context, result *oauth.ProviderResult) *User {
+	return nil
+}
+
+func (r *testVersionRepository) SaveOAuthData(ctx context.Context, result *oauth.ProviderResult) {
+}
+
+type testSocialProvider struct {
+	oauth.SocialProvider
+	result oauth.ProviderResult
+}
+
+func (p testSocialProvider) GetInfoByToken(ctx context.Context, infoToken string) (*oauth.ProviderResult, error) {
+	result := p.result
+	return &result, nil
+}
+
+func TestEntityChangesInvalidateTokens(t *testing.T) {
+	ctx := context.Background()
+	email := AuthorizationEntity{Type: EntityTypeEmail, Value: "version@test.com"}
+	phone := AuthorizationEntity{Type: EntityTypePhone, Value: "+111"}
+
+	newUseCase := func() (*DefaultUseCase, *testVersionRepository, *User) {
+		user := &User{ID: bson.NewObjectID().Hex(), Entities: []AuthorizationEntity{email, phone}, Info: map[string]any{}}
+		repository := &testVersionRepository{
+			testRefreshRepository: testRefreshRepository{testUserRepository: testUserRepository{users: map[string]*User{user.ID: user}}, refreshTokens: map[string]*RefreshToken{}},
+			versions:              map[string]int64{},
+		}
+		jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+		jwtCfg.SetRefreshTokenTTL(time.Hour)
+		jwtCfg.SetTokenVersionSource(repository)
+		useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+		useCase.RegisterSocialProvider("google", testSocialProvider{result: oauth.ProviderResult{ID: "google-id", Type: "google"}})
+		return useCase, repository, user
+	}
+
+	assertInvalidated := func(t *testing.T, useCase *DefaultUseCase, login *Response, user *User) {
+		if user.TokenVersion != 1 {
+			t.Errorf("expected token version 1, got %d", user.TokenVersion)
+		}
+		if _, err := useCase.jwtConfig.ValidateToken(ctx, login.Token); err == nil || err.Error() != tokenVersionOutdated.Error() {
+			t.Errorf("expected TOKEN_OUTDATED for the old token, got %v", err)
+		}
+		if _, err := useCase.Refresh(ctx, login.RefreshToken); err == nil {
+			t.Error("expected the old refresh token to be rejected")
+		}
+		token, err := useCase.jwtConfig.GenerateTokenFromModel(*user)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		if _, err := useCase.jwtConfig.ValidateToken(ctx, token); err != nil {
+			t.Errorf("expected a token of the new version to be valid: %v", err)
+		}
+	}
+
+	t.Run("remove entity", func(t *testing.T) {
+		useCase, repository, user := newUseCase()
+		login, err := useCase.generateResponseFor(ctx, user, nil, AuthMethodEmailCode)
+		if err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+		if err := useCase.RemoveAuthenticationEntity(ctx, *user, phone); err != nil {
+			t.Fatalf("RemoveAuthenticationEntity failed: %v", err)
+		}
+		assertInvalidated(t, useCase, login, repository.users[user.ID])
+	})
+
+	t.Run("add social entity", func(t *testing.T) {
+		useCase, _, user := newUseCase()
+		login, err := useCase.generateResponseFor(ctx, user, nil, AuthMethodEmailCode)
+		if err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+		updated, err := useCase.AddSocialAuthenticationEntity(ctx, user, SocialProviderPayload{Provider: "google", Payload: "token", PayloadType: "token"})
+		if err != nil {
+			t.Fatalf("AddSocialAuthenticationEntity failed: %v", err)
+		}
+		if len(updated.Entities) != 3 {
+			t.Errorf("expected social entity to be added, got %v", updated.Entities)
+		}
+		assertInvalidated(t, useCase, login, updated)
+	})
+}
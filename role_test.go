@@ -0,0 +1,156 @@
+package goauthlib
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/techpro-studio/gohttplib"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type testRoleRepository struct {
+	testUserRepository
+	versions map[string]int64
+}
+
+func (r *testRoleRepository) GrantRole(ctx context.Context, userId string, role string) bool {
+	user := r.users[userId]
+	if user.HasRole(role) {
+		return false
+	}
+	user.Roles = append(user.Roles, role)
+	return true
+}
+
+func (r *testRoleRepository) RevokeRole(ctx context.Context, userId string, role string) bool {
+	user := r.users[userId]
+	if !user.HasRole(role) {
+		return false
+	}
+	user.Roles = slices.DeleteFunc(user.Roles, func(r string) bool { return r == role })
+	return true
+}
+
+func (r *testRoleRepository) GetTokenVersion(ctx context.Context, userId string) (int64, error) {
+	return r.versions[userId], nil
+}
+
+func (r *testRoleRepository) IncrementTokenVersion(ctx context.Context, userId string) int64 {
+	r.versions[userId]++
+	return r.versions[userId]
+}
+
+func (r *testRoleRepository) DeleteRefreshTokensForUser(ctx context.Context, userId string) {
+}
+
+type testRoleCallback struct {
+	DoNothingUseCaseCallback
+	granted []string
+	revoked []string
+}
+
+func (c *testRoleCallback) OnGrantRole(ctx context.Context, user *User, role string) {
+	c.granted = append(c.granted, role)
+}
+
+func (c *testRoleCallback) OnRevokeRole(ctx context.Context, user *User, role string) {
+	c.revoked = append(c.revoked, role)
+}
+
+func TestGrantAndRevokeRole(t *testing.T) {
+	ctx := context.Background()
+	user := &User{ID: bson.NewObjectID().Hex()}
+	repository := &testRoleRepository{testUserRepository: testUserRepository{users: map[string]*User{user.ID: user}}, versions: map[string]int64{}}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetTokenVersionSource(repository)
+	jwtCfg.SetSlimClaims()
+	callback := &testRoleCallback{}
+	useCase := NewDefaultUseCase(repository, *jwtCfg, callback)
+
+	oldToken, err := jwtCfg.GenerateTokenFromModel(*user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	granted, err := useCase.GrantRole(ctx, user.ID, "admin")
+	if err != nil {
+		t.Fatalf("GrantRole failed: %v", err)
+	}
+	if !granted.HasRole("admin") || !slices.Equal(callback.granted, []string{"admin"}) {
+		t.Fatalf("expected admin role and callback, got %v %v", granted.Roles, callback.granted)
+	}
+	if _, err := jwtCfg.ValidateToken(ctx, oldToken); err == nil {
+		t.Error("expected token issued before role change to be rejected")
+	}
+
+	token, err := jwtCfg.GenerateTokenFromModel(*granted)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	info, err := jwtCfg.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if !info.User.HasRole("admin") {
+		t.Errorf("expected roles claim in slim token, got %v", info.User.Roles)
+	}
+
+	if _, err := useCase.GrantRole(ctx, user.ID, "admin"); err != nil || len(callback.granted) != 1 {
+		t.Errorf("expected repeated grant to be a no-op, callbacks %v", callback.granted)
+	}
+	revoked, err := useCase.RevokeRole(ctx, user.ID, "admin")
+	if err != nil {
+		t.Fatalf("RevokeRole failed: %v", err)
+	}
+	if revoked.HasRole("admin") || !slices.Equal(callback.revoked, []string{"admin"}) {
+		t.Errorf("expected admin role to be revoked, got %v %v", revoked.Roles, callback.revoked)
+	}
+}
+
+// testDeletedRoleRepository removes the user while the role changes, like a concurrent delete.
+type testDeletedRoleRepository struct {
+	testRoleRepository
+}
+
+func (r *testDeletedRoleRepository) GrantRole(ctx context.Context, userId string, role string) bool {
+	changed := r.testRoleRepository.GrantRole(ctx, userId, role)
+	delete(r.users, userId)
+	return changed
+}
+
+func (r *testDeletedRoleRepository) RevokeRole(ctx context.Context, userId string, role string) bool {
+	changed := r.testRoleRepository.RevokeRole(ctx, userId, role)
+	delete(r.users, userId)
+	return changed
+}
+
+func TestRoleChangeOfDeletedUser(t *testing.T) {
+	ctx := context.Background()
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	callback := &testRoleCallback{}
+
+	for name, change := range map[string]func(useCase *DefaultUseCase, userId string) (*User, error){
+		"grant": func(useCase *DefaultUseCase, userId string) (*User, error) {
+			return useCase.GrantRole(ctx, userId, "admin")
+		},
+		"revoke": func(useCase *DefaultUseCase, userId string) (*User, error) {
+			return useCase.RevokeRole(ctx, userId, "admin")
+		},
+	} {
+		user := &User{ID: bson.NewObjectID().Hex()}
+		if name == "revoke" {
+			user.Roles = []string{"admin"}
+		}
+		repository := &testDeletedRoleRepository{testRoleRepository{testUserRepository: testUserRepository{users: map[string]*User{user.ID: user}}, versions: map[string]int64{}}}
+		useCase := NewDefaultUseCase(repository, *jwtCfg, callback)
+		if usr, err := change(useCase, user.ID); usr != nil || err == nil || err.Error() != gohttplib.HTTP404(user.ID).Error() {
+			t.Errorf("%s: expected 404, got %v, %v", name, usr, err)
+		}
+	}
+	if len(callback.granted) != 0 || len(callback.revoked) != 0 {
+		t.Errorf("expected no callbacks for deleted user, got %v %v", callback.granted, callback.revoked)
+	}
+}
@@ -8,17 +8,52 @@ import (
 func RegisterPrivateInRouter(t *Transport, router gohttplib.Router, usrMiddleware gohttplib.Middleware, defaultMiddleWare gohttplib.Middleware) {
 	router.Post("/auth/verify", defaultMiddleWare(http.HandlerFunc(t.AuthenticateWithCodeHandler)))
 	router.Post("/auth/social", defaultMiddleWare(http.HandlerFunc(t.AuthenticateViaSocialProviderHandler)))
+	router.Post("/auth/magic", defaultMiddleWare(http.HandlerFunc(t.MagicLinkHandler)))
+	router.Post("/auth/refresh", defaultMiddleWare(http.HandlerFunc(t.RefreshHandler)))
+	router.Post("/auth/logout", defaultMiddleWare(http.HandlerFunc(t.LogoutHandler)))
 	router.Get("/user", defaultMiddleWare(usrMiddleware(http.HandlerFunc(t.CurrentUserHandler))))
 }
 
 func RegisterPublicInRouter(t *Transport, router gohttplib.Router, usrMiddleware gohttplib.Middleware, defaultMiddleWare gohttplib.Middleware) {
-	router.Post("/delete/send", defaultMiddleWare(usrMiddleware(http.HandlerFunc(t.SendVerificationCodeHandler))))
-	router.Post("/delete", defaultMiddleWare(usrMiddleware(http.HandlerFunc(t.VerifyDeleteHandler))))
-	router.Post("/force-delete", defaultMiddleWare(usrMiddleware(http.HandlerFunc(t.ForceDeleteHandler))))
-	router.Patch("/user/info", defaultMiddleWare(usrMiddleware(http.HandlerFunc(t.PatchInfoHandler))))
+	RegisterPublicInRouterWithStepUp(t, router, usrMiddleware, func(next http.Handler) http.Handler { return next }, defaultMiddleWare)
+}
+
+// RegisterPublicInRouterWithStepUp is RegisterPublicInRouter where force delete and entity removal
+// additionally pass stepUpMiddleware, see StepUpMiddlewareFactory.
+func RegisterPublicInRouterWithStepUp(t *Transport, router gohttplib.Router, usrMiddleware gohttplib.Middleware, stepUpMiddleware gohttplib.Middleware, defaultMiddleWare gohttplib.Middleware) {
+	router.Post("/delete/send", defaultMiddleWare(usrMiddleware(DenyImpersonationMiddleware(http.HandlerFunc(t.SendVerificationCodeHandler)))))
+	router.Post("/delete", defaultMiddleWare(usrMiddleware(DenyImpersonationMiddleware(http.HandlerFunc(t.VerifyDeleteHandler)))))
+	router.Post("/force-delete", defaultMiddleWare(usrMiddleware(DenyImpersonationMiddleware(stepUpMiddleware(http.HandlerFunc(t.ForceDeleteHandler))))))
+	router.Patch("/user/info", defaultMiddleWare(usrMiddleware(DenyReadOnlyImpersonationMiddleware(http.HandlerFunc(t.PatchInfoHandler)))))
 	router.Post("/auth/send", defaultMiddleWare(http.HandlerFunc(t.SendCodeHandler)))
-	router.Post("/user/entity/remove", defaultMiddleWare(usrMiddleware(http.HandlerFunc(t.RemoveAuthenticationEntityHandler))))
-	router.Post("/user/entity/social", defaultMiddleWare(usrMiddleware(http.HandlerFunc(t.AddSocialAuthenticationEntityHandler))))
-	router.Post("/user/entity/verify", defaultMiddleWare(usrMiddleware(http.HandlerFunc(t.VerifyAuthenticationEntityHandler))))
-	router.Post("/user/entity/send", defaultMiddleWare(usrMiddleware(http.HandlerFunc(t.SendCodeWithUserHandler))))
+	router.Post("/auth/magic/send", defaultMiddleWare(http.HandlerFunc(t.SendMagicLinkHandler)))
+	router.Post("/user/entity/remove", defaultMiddleWare(usrMiddleware(DenyImpersonationMiddleware(stepUpMiddleware(http.HandlerFunc(t.RemoveAuthenticationEntityHandler))))))
+	router.Post("/user/entity/social", defaultMiddleWare(usrMiddleware(DenyReadOnlyImpersonationMiddleware(http.HandlerFunc(t.AddSocialAuthenticationEntityHandler)))))
+	router.Post("/user/entity/verify", defaultMiddleWare(usrMiddleware(DenyReadOnlyImpersonationMiddleware(http.HandlerFunc(t.VerifyAuthenticationEntityHandler)))))
+	router.Post("/user/entity/send", defaultMiddleWare(usrMiddleware(DenyReadOnlyImpersonationMiddleware(http.HandlerFunc(t.SendCodeWithUserHandler)))))
+	router.Get("/user/sessions", defaultMiddleWare(usrMiddleware(http.HandlerFunc(t.ListSessionsHandler))))
+	router.Post("/user/sessions/revoke", defaultMiddleWare(usrMiddleware(DenyReadOnlyImpersonationMiddleware(http.HandlerFunc(t.RevokeSessionHandler)))))
+	router.Post("/user/sessions/revoke-all", defaultMiddleWare(usrMiddleware(DenyImpersonationMiddleware(http.HandlerFunc(t.RevokeAllSessionsHandler)))))
+	router.Post("/user/tokens/invalidate", defaultMiddleWare(usrMiddleware(DenyImpersonationMiddleware(http.HandlerFunc(t.InvalidateTokensHandler)))))
+}
+
+// RegisterAdminInRouter registers admin endpoints. adminMiddleware runs after usrMiddleware and must allow only admins,
+// e.g. RequireRole("admin").
+// Stop of impersonation is called with the impersonation token, so it is not guarded by adminMiddleware.
+func RegisterAdminInRouter(t *Transport, router gohttplib.Router, usrMiddleware gohttplib.Middleware, adminMiddleware gohttplib.Middleware, defaultMiddleWare gohttplib.Middleware) {
+	router.Post("/admin/impersonate", defaultMiddleWare(usrMiddleware(adminMiddleware(DenyImpersonationMiddleware(http.HandlerFunc(t.StartImpersonationHandler))))))
+	router.Post("/admin/impersonate/stop", defaultMiddleWare(usrMiddleware(http.HandlerFunc(t.StopImpersonationHandler))))
+	router.Post("/admin/roles/grant", defaultMiddleWare(usrMiddleware(adminMiddleware(DenyImpersonationMiddleware(http.HandlerFunc(t.GrantRoleHandler))))))
+	router.Post("/admin/roles/revoke", defaultMiddleWare(usrMiddleware(adminMiddleware(DenyImpersonationMiddleware(http.HandlerFunc(t.RevokeRoleHandler))))))
+}
+
+func RegisterWellKnownInRouter(config JWTConfig, router gohttplib.Router, defaultMiddleWare gohttplib.Middleware) {
+	router.Get("/.well-known/jwks.json", defaultMiddleWare(JWKSHandlerFactory(config)))
+}
+
+// RegisterServiceInRouter registers endpoints for other backend services. serviceMiddleware authenticates them,
+// see ServiceClientMiddlewareFactory.
+func RegisterServiceInRouter(t *Transport, router gohttplib.Router, serviceMiddleware gohttplib.Middleware, defaultMiddleWare gohttplib.Middleware) {
+	router.Post("/auth/introspect", defaultMiddleWare(serviceMiddleware(http.HandlerFunc(t.IntrospectHandler))))
+	router.Post("/auth/token", defaultMiddleWare(serviceMiddleware(http.HandlerFunc(t.TokenExchangeHandler))))
 }
@@ -0,0 +1,151 @@
+package goauthlib
+
+import (
+	"context"
+	"github.com/techpro-studio/gohttplib"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CounterStore counts events in fixed windows, e.g. codes sent to a destination per hour.
+type CounterStore interface {
+	// Increment counts an event of the key. The window starts with the first event of the key.
+	// It returns the number of events in the current window, including this one, and the end of the window.
+	Increment(ctx context.Context, key string, window time.Duration) (int64, time.Time, error)
+}
+
+// SendQuota limits sending of codes by SendCode. Zero value of a field disables its limit.
+// Limits per IP use ClientInfo of the context.
+type SendQuota struct {
+	// Cooldown is the minimal time between codes sent to the same entity.
+	Cooldown             time.Duration
+	HourlyPerDestination int64
+	DailyPerDestination  int64
+	HourlyPerIP          int64
+	DailyPerIP           int64
+}
+
+func DefaultSendQuota() SendQuota {
+	return SendQuota{
+		Cooldown:             time.Minute,
+		HourlyPerDestination: 5,
+		DailyPerDestination:  10,
+		HourlyPerIP:          20,
+		DailyPerIP:           100,
+	}
+}
+
+type sendLimit struct {
+	key    string
+	window time.Duration
+	max    int64
+	code   string
+}
+
+const (
+	sendCooldownCode      = "SEND_COOLDOWN"
+	sendQuotaExceededCode = "SEND_QUOTA_EXCEEDED"
+)
+
+// sendLimitError tells the client when it can request a code again, retry_after is in seconds.
+func sendLimitError(code string, retryAfter time.Duration) error {
+	description := "Too many codes are sent, try later"
+	if code == sendCooldownCode {
+		description = "Code is sent recently, try later"
+	}
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	return gohttplib.NewServerError(429, code, description, "value", map[string]interface{}{"retry_after": seconds})
+}
+
+// checkSendQuota counts the send in every limit and fails on the first exceeded one.
+// Limits of the IP go first, so a client over its quota can't start cooldown of someone's destination.
+func (useCase *DefaultUseCase) checkSendQuota(ctx context.Context, entity AuthorizationEntity) error {
+	if useCase.counters == nil {
+		return nil
+	}
+	quota := useCase.sendQuota
+	var limits []sendLimit
+	if ip := ClientInfoFromContext(ctx).IP; ip != "" {
+		limits = append(limits,
+			sendLimit{"send:ip:" + ip + ":hour", time.Hour, quota.HourlyPerIP, sendQuotaExceededCode},
+			sendLimit{"send:ip:" + ip + ":day", 24 * time.Hour, quota.DailyPerIP, sendQuotaExceededCode},
+		)
+	}
+	destination := "send:" + entity.Type + ":" + normalizedDestination(entity)
+	limits = append(limits,
+		sendLimit{destination + ":cooldown", quota.Cooldown, 1, sendCooldownCode},
+		sendLimit{destination + ":hour", time.Hour, quota.HourlyPerDestination, sendQuotaExceededCode},
+		sendLimit{destination + ":day", 24 * time.Hour, quota.DailyPerDestination, sendQuotaExceededCode},
+	)
+	for _, limit := range limits {
+		if limit.window <= 0 || limit.max <= 0 {
+			continue
+		}
+		count, resetAt, err := useCase.counters.Increment(ctx, limit.key, limit.window)
+		if err != nil {
+			return err
+		}
+		if count > limit.max {
+			return sendLimitError(limit.code, time.Until(resetAt))
+		}
+	}
+	return nil
+}
+
+// normalizedDestination makes spellings of the same destination share quotas: emails are lowercased,
+// phones are reduced to E.164 form, e.g. "+1 (555) 010-0000" and "001555 0100000" become "+15550100000".
+func normalizedDestination(entity AuthorizationEntity) string {
+	value := strings.TrimSpace(entity.Value)
+	switch entity.Type {
+	case EntityTypeEmail:
+		return strings.ToLower(value)
+	case EntityTypePhone:
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, value)
+		if !strings.HasPrefix(value, "+") {
+			digits = strings.TrimPrefix(digits, "00")
+		}
+		return "+" + digits
+	default:
+		return value
+	}
+}
+
+// MemoryCounterStore is a CounterStore for a single instance deployments and tests.
+type MemoryCounterStore struct {
+	mu       sync.Mutex
+	counters map[string]*memoryCounter
+}
+
+type memoryCounter struct {
+	count   int64
+	resetAt time.Time
+}
+
+func NewMemoryCounterStore() *MemoryCounterStore {
+	return &MemoryCounterStore{counters: map[string]*memoryCounter{}}
+}
+
+func (s *MemoryCounterStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, counter := range s.counters {
+		if !counter.resetAt.After(now) {
+			delete(s.counters, k)
+		}
+	}
+	counter, ok := s.counters[key]
+	if !ok {
+		counter = &memoryCounter{resetAt: now.Add(window)}
+		s.counters[key] = counter
+	}
+	counter.count++
+	return counter.count, counter.resetAt, nil
+}
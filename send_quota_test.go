@@ -0,0 +1,103 @@
+package goauthlib
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type testOTPDelivery struct {
+	sent []string
+}
+
+func (d *testOTPDelivery) SendOTP(ctx context.Context, destination, otp string) error {
+	d.sent = append(d.sent, otp)
+	return nil
+}
+
+type testSendRepository struct {
+	testUserRepository
+	codeHash string
+}
+
+func (r *testSendRepository) CreateVerificationForEntity(ctx context.Context, entity AuthorizationEntity, codeHash string) {
+	r.codeHash = codeHash
+}
+
+func TestSendQuota(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	delivery := &testOTPDelivery{}
+	useCase := NewDefaultUseCase(&testSendRepository{}, *jwtCfg, DoNothingCallback())
+	useCase.RegisterOTPDelivery(EntityTypeEmail, delivery)
+	useCase.SetSendQuota(NewMemoryCounterStore(), SendQuota{Cooldown: time.Minute, HourlyPerIP: 3})
+
+	ctx := WithClientInfo(context.Background(), ClientInfo{IP: "10.0.0.1"})
+	first := AuthorizationEntity{Type: EntityTypeEmail, Value: "first@test.com"}
+	second := AuthorizationEntity{Type: EntityTypeEmail, Value: "second@test.com"}
+	third := AuthorizationEntity{Type: EntityTypeEmail, Value: "third@test.com"}
+
+	if err := useCase.SendCode(ctx, first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := useCase.SendCode(ctx, first)
+	if err == nil || err.Error() != sendLimitError(sendCooldownCode, time.Minute).Error() {
+		t.Fatalf("expected cooldown error, got %v", err)
+	}
+	if err := useCase.SendCode(ctx, second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = useCase.SendCode(ctx, third)
+	if err == nil || err.Error() != sendLimitError(sendQuotaExceededCode, time.Hour).Error() {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+	// Another client isn't affected by the quota of the IP
+	other := WithClientInfo(context.Background(), ClientInfo{IP: "10.0.0.2"})
+	if err := useCase.SendCode(other, third); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(delivery.sent) != 3 {
+		t.Errorf("expected 3 sent codes, got %d", len(delivery.sent))
+	}
+}
+
+func TestSendQuotaNormalizesDestination(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	delivery := &testOTPDelivery{}
+	useCase := NewDefaultUseCase(&testSendRepository{}, *jwtCfg, DoNothingCallback())
+	useCase.RegisterOTPDelivery(EntityTypeEmail, delivery)
+	useCase.RegisterOTPDelivery(EntityTypePhone, delivery)
+	useCase.SetSendQuota(NewMemoryCounterStore(), SendQuota{Cooldown: time.Minute})
+	ctx := context.Background()
+
+	for _, spellings := range [][]string{
+		{EntityTypeEmail, "User@Test.com", " user@test.com "},
+		{EntityTypePhone, "+1 (555) 010-0000", "0015550100000"},
+	} {
+		if err := useCase.SendCode(ctx, AuthorizationEntity{Type: spellings[0], Value: spellings[1]}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err := useCase.SendCode(ctx, AuthorizationEntity{Type: spellings[0], Value: spellings[2]})
+		if err == nil || err.Error() != sendLimitError(sendCooldownCode, time.Minute).Error() {
+			t.Errorf("expected %q to share cooldown of %q, got %v", spellings[2], spellings[1], err)
+		}
+	}
+}
+
+func TestMemoryCounterStore(t *testing.T) {
+	store := NewMemoryCounterStore()
+	ctx := context.Background()
+	count, resetAt, _ := store.Increment(ctx, "key", time.Hour)
+	if count != 1 || time.Until(resetAt) <= 0 {
+		t.Fatalf("unexpected counter %d, %v", count, resetAt)
+	}
+	if count, _, _ = store.Increment(ctx, "key", time.Hour); count != 2 {
+		t.Fatalf("expected count 2, got %d", count)
+	}
+	store.Increment(ctx, "short", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if count, _, _ = store.Increment(ctx, "short", time.Millisecond); count != 1 {
+		t.Fatalf("expected a new window, got count %d", count)
+	}
+}
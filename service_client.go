@@ -0,0 +1,88 @@
+package goauthlib
+
+import (
+	"context"
+	"crypto/sha3"
+	"crypto/subtle"
+	"github.com/techpro-studio/gohttplib"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+const CurrentServiceClientContextKey = "current_service_client_key"
+
+// ServiceClient is a backend service which calls auth endpoints on its own behalf.
+// Audiences and Scopes limit tokens the client can get by token exchange.
+type ServiceClient struct {
+	ID        string
+	Audiences []string
+	Scopes    []string
+}
+
+type ServiceClientRegistry interface {
+	Authenticate(ctx context.Context, clientId, clientSecret string) *ServiceClient
+}
+
+type staticServiceClient struct {
+	client     ServiceClient
+	secretHash [32]byte
+}
+
+// StaticServiceClientRegistry keeps clients configured on startup. Only hashes of secrets are kept.
+type StaticServiceClientRegistry struct {
+	mu      sync.RWMutex
+	clients map[string]staticServiceClient
+}
+
+func NewStaticServiceClientRegistry() *StaticServiceClientRegistry {
+	return &StaticServiceClientRegistry{clients: map[string]staticServiceClient{}}
+}
+
+func (r *StaticServiceClientRegistry) Register(client ServiceClient, secret string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[client.ID] = staticServiceClient{client: client, secretHash: sha3.Sum256([]byte(secret))}
+}
+
+func (r *StaticServiceClientRegistry) Authenticate(ctx context.Context, clientId, clientSecret string) *ServiceClient {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stored, ok := r.clients[clientId]
+	secretHash := sha3.Sum256([]byte(clientSecret))
+	if !ok || subtle.ConstantTimeCompare(stored.secretHash[:], secretHash[:]) != 1 {
+		return nil
+	}
+	client := stored.client
+	client.Audiences = slices.Clone(client.Audiences)
+	client.Scopes = slices.Clone(client.Scopes)
+	return &client
+}
+
+// ServiceClientMiddlewareFactory authenticates service clients by HTTP Basic credentials.
+func ServiceClientMiddlewareFactory(registry ServiceClientRegistry) gohttplib.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			clientId, clientSecret, ok := req.BasicAuth()
+			if !ok {
+				gohttplib.HTTP401().Write(w)
+				return
+			}
+			client := registry.Authenticate(req.Context(), clientId, clientSecret)
+			if client == nil {
+				gohttplib.HTTP401().Write(w)
+				return
+			}
+			ctx := context.WithValue(req.Context(), CurrentServiceClientContextKey, client)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+func GetServiceClientFromRequest(req *http.Request) *ServiceClient {
+	client, ok := req.Context().Value(CurrentServiceClientContextKey).(*ServiceClient)
+	if !ok {
+		return nil
+	}
+	return client
+}
@@ -0,0 +1,135 @@
+package goauthlib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestStaticServiceClientRegistry(t *testing.T) {
+	registry := NewStaticServiceClientRegistry()
+	registry.Register(ServiceClient{ID: "billing"}, "secret")
+
+	if client := registry.Authenticate(context.Background(), "billing", "secret"); client == nil || client.ID != "billing" {
+		t.Fatalf("expected billing client, got %v", client)
+	}
+	if client := registry.Authenticate(context.Background(), "billing", "wrong"); client != nil {
+		t.Fatal("wrong secret should not authenticate")
+	}
+	if client := registry.Authenticate(context.Background(), "unknown", "secret"); client != nil {
+		t.Fatal("unknown client should not authenticate")
+	}
+}
+
+func TestServiceClientMiddleware(t *testing.T) {
+	registry := NewStaticServiceClientRegistry()
+	registry.Register(ServiceClient{ID: "billing"}, "secret")
+
+	var seen *ServiceClient
+	handler := ServiceClientMiddlewareFactory(registry)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetServiceClientFromRequest(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/introspect", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || seen != nil {
+		t.Fatalf("expected 401 without credentials, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/auth/introspect", nil)
+	req.SetBasicAuth("billing", "secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if seen == nil || seen.ID != "billing" {
+		t.Fatalf("expected billing client in context, got %v", seen)
+	}
+}
+
+func TestIntrospect(t *testing.T) {
+	ctx := context.Background()
+	user := &User{ID: bson.NewObjectID().Hex(), Entities: []AuthorizationEntity{{Type: EntityTypeEmail, Value: "introspect@test.com"}}}
+	repository := &testVersionRepository{
+		testRefreshRepository: testRefreshRepository{testUserRepository: testUserRepository{users: map[string]*User{user.ID: user}}, refreshTokens: map[string]*RefreshToken{}},
+		versions:              map[string]int64{},
+	}
+	sessions := &testSessionRepository{sessions: map[string]*Session{}}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetAccessTokenTTL(time.Hour)
+	jwtCfg.SetAudience("billing")
+	jwtCfg.SetRevocationStore(NewMemoryRevocationStore())
+	jwtCfg.SetSessionRepository(sessions)
+	jwtCfg.SetTokenVersionSource(repository)
+	useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+
+	newToken := func(t *testing.T) (string, *Session) {
+		session := sessions.CreateSession(ctx, Session{UserID: user.ID})
+		user.TokenVersion = repository.versions[user.ID]
+		token, err := jwtCfg.GenerateToken(ctx, *user, TokenOptions{SessionID: session.ID, Scope: []string{"billing:read"}, ClientID: "orders"})
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		return token, session
+	}
+
+	t.Run("active", func(t *testing.T) {
+		token, session := newToken(t)
+		response, err := useCase.Introspect(ctx, token)
+		if err != nil {
+			t.Fatalf("Introspect failed: %v", err)
+		}
+		if !response.Active || response.Subject != user.ID || response.SessionID != session.ID || response.TokenID == "" {
+			t.Errorf("unexpected response %+v", response)
+		}
+		if response.ExpiresAt == 0 || response.IssuedAt == 0 || response.ExpiresAt-response.IssuedAt != int64(time.Hour.Seconds()) {
+			t.Errorf("unexpected exp %d and iat %d", response.ExpiresAt, response.IssuedAt)
+		}
+		if response.Scope != "billing:read" || response.ClientID != "orders" || !slices.Equal(response.Audience, []string{"billing"}) {
+			t.Errorf("unexpected scope %q, client %q, audience %v", response.Scope, response.ClientID, response.Audience)
+		}
+		if response.TokenType != "Bearer" || len(response.Entities) != 1 {
+			t.Errorf("unexpected token type %q or entities %v", response.TokenType, response.Entities)
+		}
+	})
+
+	inactive := []struct {
+		name   string
+		revoke func(t *testing.T, token string, session *Session)
+	}{
+		{"revoked jti", func(t *testing.T, token string, session *Session) {
+			info, err := jwtCfg.ParseToken(token)
+			if err != nil {
+				t.Fatalf("ParseToken failed: %v", err)
+			}
+			if err := jwtCfg.RevokeToken(ctx, info); err != nil {
+				t.Fatalf("RevokeToken failed: %v", err)
+			}
+		}},
+		{"revoked session", func(t *testing.T, token string, session *Session) {
+			sessions.RevokeSession(ctx, user.ID, session.ID)
+		}},
+		{"outdated version", func(t *testing.T, token string, session *Session) {
+			repository.IncrementTokenVersion(ctx, user.ID)
+		}},
+	}
+	for _, tc := range inactive {
+		t.Run(tc.name, func(t *testing.T) {
+			token, session := newToken(t)
+			tc.revoke(t, token, session)
+			response, err := useCase.Introspect(ctx, token)
+			if err != nil {
+				t.Fatalf("Introspect failed: %v", err)
+			}
+			if response.Active || response.Subject != "" {
+				t.Errorf("expected inactive token without details, got %+v", response)
+			}
+		})
+	}
+}
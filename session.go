@@ -0,0 +1,145 @@
+package goauthlib
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const ClientInfoContextKey = "client_info_key"
+
+// sessionTouchInterval limits how often last seen time is written for the same session.
+const sessionTouchInterval = time.Minute
+
+// ClientInfo describes the client which makes a request. It is stored in new sessions.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	Device    string
+}
+
+// ClientInfoFromRequest takes IP from the remote address of the connection, forwarded headers are ignored.
+// Use TrustedProxies.ClientInfoFromRequest behind proxies.
+func ClientInfoFromRequest(req *http.Request) ClientInfo {
+	var proxies *TrustedProxies
+	return proxies.ClientInfoFromRequest(req)
+}
+
+// TrustedProxies resolves client IP behind proxies. Forwarded headers are honored only when the request
+// comes from one of the networks, so clients can't choose the IP which send quotas count.
+type TrustedProxies struct {
+	networks []*net.IPNet
+}
+
+// NewTrustedProxies accepts networks in CIDR notation or single IPs, e.g. "10.0.0.0/8" or "127.0.0.1".
+func NewTrustedProxies(networks ...string) (*TrustedProxies, error) {
+	proxies := &TrustedProxies{}
+	for _, network := range networks {
+		if !strings.Contains(network, "/") {
+			ip := net.ParseIP(network)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", network)
+			}
+			network = ip.String() + "/128"
+			if ip.To4() != nil {
+				network = ip.String() + "/32"
+			}
+		}
+		_, parsed, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, err
+		}
+		proxies.networks = append(proxies.networks, parsed)
+	}
+	return proxies, nil
+}
+
+func (p *TrustedProxies) ClientInfoFromRequest(req *http.Request) ClientInfo {
+	forwarded := req.Header.Values("X-Forwarded-For")
+	if len(forwarded) == 0 && req.Header.Get("X-Real-IP") != "" {
+		forwarded = []string{req.Header.Get("X-Real-IP")}
+	}
+	return ClientInfo{
+		IP:        p.ClientIP(req.RemoteAddr, forwarded...),
+		UserAgent: req.UserAgent(),
+		Device:    req.Header.Get("X-Device"),
+	}
+}
+
+// ClientIP returns the host of remote address, or the address forwarded by trusted proxies.
+// Forwarded values are read from the right, the first address which is not a trusted proxy is the client.
+// Nil TrustedProxies trusts nobody.
+func (p *TrustedProxies) ClientIP(remoteAddr string, forwarded ...string) string {
+	ip := hostOf(remoteAddr)
+	if !p.trusts(ip) {
+		return ip
+	}
+	var hops []string
+	for _, value := range forwarded {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if net.ParseIP(hops[i]) == nil {
+			break
+		}
+		ip = hops[i]
+		if !p.trusts(ip) {
+			break
+		}
+	}
+	return ip
+}
+
+func hostOf(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+func (p *TrustedProxies) trusts(ip string) bool {
+	if p == nil {
+		return false
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range p.networks {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, ClientInfoContextKey, info)
+}
+
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(ClientInfoContextKey).(ClientInfo)
+	return info
+}
+
+func checkSession(ctx context.Context, sessions SessionRepository, info *TokenInfo) error {
+	if info.SessionID == "" {
+		return sessionRevoked
+	}
+	session := sessions.GetSession(ctx, info.SessionID)
+	if session == nil || session.Revoked || session.UserID != info.User.ID {
+		return sessionRevoked
+	}
+	now := time.Now().Unix()
+	if now-session.LastSeenAt >= int64(sessionTouchInterval.Seconds()) {
+		sessions.TouchSession(ctx, session.ID, now)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package goauthlib
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type testSessionRepository struct {
+	sessions map[string]*Session
+}
+
+func (r *testSessionRepository) CreateSession(ctx context.Context, session Session) *Session {
+	session.ID = bson.NewObjectID().Hex()
+	r.sessions[session.ID] = &session
+	return &session
+}
+
+func (r *testSessionRepository) GetSession(ctx context.Context, id string) *Session {
+	return r.sessions[id]
+}
+
+func (r *testSessionRepository) TouchSession(ctx context.Context, id string, lastSeenAt int64) {
+	r.sessions[id].LastSeenAt = lastSeenAt
+}
+
+func (r *testSessionRepository) ListSessions(ctx context.Context, userId string) []*Session {
+	var list []*Session
+	for _, session := range r.sessions {
+		if session.UserID == userId && !session.Revoked {
+			list = append(list, session)
+		}
+	}
+	return list
+}
+
+func (r *testSessionRepository) RevokeSession(ctx context.Context, userId, id string) bool {
+	session := r.sessions[id]
+	if session == nil || session.UserID != userId {
+		return false
+	}
+	session.Revoked = true
+	return true
+}
+
+func (r *testSessionRepository) RevokeAllSessions(ctx context.Context, userId string) {
+	for _, session := range r.sessions {
+		if session.UserID == userId {
+			session.Revoked = true
+		}
+	}
+}
+
+func TestValidateTokenWithSessions(t *testing.T) {
+	ctx := context.Background()
+	user := User{ID: bson.NewObjectID().Hex()}
+	sessions := &testSessionRepository{sessions: map[string]*Session{}}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetSessionRepository(sessions)
+
+	session := sessions.CreateSession(ctx, Session{UserID: user.ID})
+	token, err := jwtCfg.GenerateToken(ctx, user, TokenOptions{SessionID: session.ID})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	info, err := jwtCfg.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if info.SessionID != session.ID {
+		t.Errorf("expected session %s, got %s", session.ID, info.SessionID)
+	}
+	if session.LastSeenAt == 0 {
+		t.Error("expected session last seen time to be updated")
+	}
+
+	withoutSession, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if _, err := jwtCfg.ValidateToken(ctx, withoutSession); err == nil {
+		t.Error("expected token without session to be rejected")
+	}
+
+	sessions.RevokeSession(ctx, user.ID, session.ID)
+	if _, err := jwtCfg.ValidateToken(ctx, token); err == nil {
+		t.Error("expected token of revoked session to be rejected")
+	}
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/user", nil)
+	req.Header.Set("Authorization", "JWT "+token)
+	UserMiddlewareFactory(*jwtCfg)(nil).ServeHTTP(recorder, req)
+	if recorder.Code != 401 {
+		t.Errorf("expected 401 for revoked session, got %d", recorder.Code)
+	}
+}
+
+type testBrokenSessionRepository struct {
+	testSessionRepository
+}
+
+func (r *testBrokenSessionRepository) CreateSession(ctx context.Context, session Session) *Session {
+	return nil
+}
+
+func TestLoginWithoutCreatedSession(t *testing.T) {
+	user := &User{ID: bson.NewObjectID().Hex()}
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetSessionRepository(&testBrokenSessionRepository{})
+	useCase := NewDefaultUseCase(&testUserRepository{users: map[string]*User{user.ID: user}}, *jwtCfg, DoNothingCallback())
+
+	if _, err := useCase.generateResponseFor(context.Background(), user, nil, AuthMethodEmailCode); err == nil || err.Error() != sessionNotCreated.Error() {
+		t.Errorf("expected SESSION_NOT_CREATED, got %v", err)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := NewTrustedProxies("10.0.0.0/8", "192.168.1.1")
+	if err != nil {
+		t.Fatalf("NewTrustedProxies failed: %v", err)
+	}
+	if _, err := NewTrustedProxies("proxy"); err == nil {
+		t.Error("expected invalid proxy to be rejected")
+	}
+
+	cases := []struct {
+		name       string
+		proxies    *TrustedProxies
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{"remote address by default", nil, "203.0.113.7:5000", map[string]string{"X-Forwarded-For": "1.1.1.1", "X-Real-IP": "2.2.2.2"}, "203.0.113.7"},
+		{"untrusted remote address", proxies, "203.0.113.7:5000", map[string]string{"X-Forwarded-For": "1.1.1.1"}, "203.0.113.7"},
+		{"trusted proxy", proxies, "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "1.1.1.1"}, "1.1.1.1"},
+		{"spoofed hops are skipped", proxies, "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "6.6.6.6, 1.1.1.1, 192.168.1.1"}, "1.1.1.1"},
+		{"real ip header", proxies, "192.168.1.1:5000", map[string]string{"X-Real-IP": "2.2.2.2"}, "2.2.2.2"},
+		{"invalid hop", proxies, "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "unknown"}, "10.0.0.2"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/auth/send", nil)
+			req.RemoteAddr = c.remoteAddr
+			for key, value := range c.headers {
+				req.Header.Set(key, value)
+			}
+			if ip := c.proxies.ClientInfoFromRequest(req).IP; ip != c.expected {
+				t.Errorf("expected %s, got %s", c.expected, ip)
+			}
+		})
+	}
+	req := httptest.NewRequest("POST", "/auth/send", nil)
+	req.RemoteAddr = "10.0.0.2:5000"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1")
+	if ip := ClientInfoFromRequest(req).IP; ip != "10.0.0.2" {
+		t.Errorf("expected ClientInfoFromRequest to ignore forwarded headers, got %s", ip)
+	}
+}
@@ -0,0 +1,162 @@
+package goauthlib
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Signer signs tokens with a key which doesn't have to live in process memory:
+// PKCS#11 module, sidecar signer, keystore etc.
+// Sign returns signature in JWS format for the algorithm of the key it is used with.
+type Signer interface {
+	Sign(ctx context.Context, signingInput []byte) ([]byte, error)
+	PublicKey() crypto.PublicKey
+}
+
+func signToken(ctx context.Context, tokenObj *jwt.Token, key JWTKey) (string, error) {
+	if key.Signer == nil {
+		return tokenObj.SignedString(key.SigningKey)
+	}
+	signingString, err := tokenObj.SigningString()
+	if err != nil {
+		return "", err
+	}
+	signature, err := key.Signer.Sign(ctx, []byte(signingString))
+	if err != nil {
+		return "", err
+	}
+	return signingString + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// LocalSigner signs with a key in process memory.
+type LocalSigner struct {
+	signingMethod jwt.SigningMethod
+	key           crypto.Signer
+}
+
+func NewLocalSigner(signingMethod jwt.SigningMethod, key crypto.Signer) *LocalSigner {
+	return &LocalSigner{signingMethod: signingMethod, key: key}
+}
+
+func (s *LocalSigner) Sign(ctx context.Context, signingInput []byte) ([]byte, error) {
+	return s.signingMethod.Sign(string(signingInput), s.key)
+}
+
+func (s *LocalSigner) PublicKey() crypto.PublicKey {
+	return s.key.Public()
+}
+
+// PEMFileSigner signs with a private key from PEM file and reloads it when the file changes.
+// Tokens signed with the previous key are not valid after reload, keep it in KeySet to rotate gradually.
+type PEMFileSigner struct {
+	path          string
+	signingMethod jwt.SigningMethod
+	mu            sync.RWMutex
+	key           crypto.Signer
+	modTime       time.Time
+}
+
+func NewPEMFileSigner(path string, signingMethod jwt.SigningMethod) (*PEMFileSigner, error) {
+	signer := &PEMFileSigner{path: path, signingMethod: signingMethod}
+	_, err := signer.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// Reload reads the file if it was modified since the last read and reports whether the key was replaced.
+func (s *PEMFileSigner) Reload() (bool, error) {
+	stat, err := os.Stat(s.path)
+	if err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	unchanged := s.key != nil && stat.ModTime().Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	bytes, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, err
+	}
+	key, err := ParsePrivateKeyPEM(bytes)
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+	s.modTime = stat.ModTime()
+	return true, nil
+}
+
+// Watch checks the file every interval until ctx is done. Errors are logged and the previous key is kept.
+func (s *PEMFileSigner) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := s.Reload()
+			if err != nil {
+				log.Printf("Failed to reload signing key %s: %s", s.path, err.Error())
+			}
+		}
+	}
+}
+
+func (s *PEMFileSigner) currentKey() crypto.Signer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.key
+}
+
+func (s *PEMFileSigner) Sign(ctx context.Context, signingInput []byte) ([]byte, error) {
+	return s.signingMethod.Sign(string(signingInput), s.currentKey())
+}
+
+func (s *PEMFileSigner) PublicKey() crypto.PublicKey {
+	return s.currentKey().Public()
+}
+
+// ParsePrivateKeyPEM parses PKCS#8, PKCS#1 RSA or SEC 1 EC private key.
+func ParsePrivateKeyPEM(bytes []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(bytes)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+	return signer, nil
+}
@@ -0,0 +1,118 @@
+package goauthlib
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func writePrivateKeyPEM(t *testing.T, path string, key any) {
+	bytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: bytes}), 0600)
+	if err != nil {
+		t.Fatalf("failed to write private key: %v", err)
+	}
+}
+
+func TestLocalSigner(t *testing.T) {
+	user := User{ID: bson.NewObjectID().Hex()}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %v", err)
+	}
+
+	jwtCfg := NewJWTConfigWithSigner(jwt.SigningMethodES256, NewLocalSigner(jwt.SigningMethodES256, ecKey), "test-blinder")
+	token, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	verifier := NewJWTConfig(jwt.SigningMethodES256, nil, &ecKey.PublicKey, "test-blinder")
+	validUser, err := verifier.GetValidUserFromToken(token)
+	if err != nil {
+		t.Fatalf("GetValidUserFromToken failed: %v", err)
+	}
+	if validUser.ID != user.ID {
+		t.Errorf("expected ID %s, got %s", user.ID, validUser.ID)
+	}
+	if len(jwtCfg.JWKS().Keys) != 1 {
+		t.Error("expected public key of signer to be published")
+	}
+}
+
+func TestPEMFileSignerReload(t *testing.T) {
+	user := User{ID: bson.NewObjectID().Hex()}
+	path := filepath.Join(t.TempDir(), "signing.pem")
+
+	_, firstKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key pair: %v", err)
+	}
+	writePrivateKeyPEM(t, path, firstKey)
+
+	signer, err := NewPEMFileSigner(path, jwt.SigningMethodEdDSA)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	jwtCfg := NewJWTConfigWithSigner(jwt.SigningMethodEdDSA, signer, "test-blinder")
+
+	firstToken, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if _, err := jwtCfg.GetValidUserFromToken(firstToken); err != nil {
+		t.Fatalf("GetValidUserFromToken failed: %v", err)
+	}
+
+	reloaded, err := signer.Reload()
+	if err != nil || reloaded {
+		t.Fatalf("expected unchanged file not to be reloaded: %v", err)
+	}
+
+	_, secondKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key pair: %v", err)
+	}
+	writePrivateKeyPEM(t, path, secondKey)
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("failed to touch key file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go signer.Watch(ctx, 10*time.Millisecond)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !secondKey.Public().(ed25519.PublicKey).Equal(signer.PublicKey()) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected signer to reload the key")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	secondToken, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if _, err := jwtCfg.GetValidUserFromToken(secondToken); err != nil {
+		t.Errorf("expected token signed with reloaded key to be valid: %v", err)
+	}
+	if _, err := jwtCfg.GetValidUserFromToken(firstToken); err == nil {
+		t.Error("expected token signed with replaced key to be rejected")
+	}
+}
@@ -0,0 +1,55 @@
+package goauthlib
+
+import (
+	"slices"
+	"strings"
+)
+
+// Token exchange values of RFC 8693.
+const (
+	TokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	AccessTokenType        = "urn:ietf:params:oauth:token-type:access_token"
+	JWTTokenType           = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+type TokenExchangeRequest struct {
+	SubjectToken string
+	Audience     string
+	Scope        []string
+}
+
+type TokenExchangeResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in,omitempty"`
+	Scope           string `json:"scope,omitempty"`
+}
+
+func parseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// exchangeScope down-scopes the token. Requested scope must be allowed for the client and, when the subject token
+// is scoped, be a part of its scope. Empty request gets everything allowed. Empty result is never granted,
+// because token without scope is not limited at all.
+func exchangeScope(requested, subject, allowed []string) ([]string, bool) {
+	available := allowed
+	if len(subject) > 0 {
+		available = nil
+		for _, scope := range allowed {
+			if slices.Contains(subject, scope) {
+				available = append(available, scope)
+			}
+		}
+	}
+	if len(requested) == 0 {
+		return available, len(available) > 0
+	}
+	for _, scope := range requested {
+		if !slices.Contains(available, scope) {
+			return nil, false
+		}
+	}
+	return requested, true
+}
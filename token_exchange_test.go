@@ -0,0 +1,174 @@
+package goauthlib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestExchangeScope(t *testing.T) {
+	cases := []struct {
+		name      string
+		requested []string
+		subject   []string
+		allowed   []string
+		expected  []string
+		ok        bool
+	}{
+		{"all allowed by default", nil, nil, []string{"read", "write"}, []string{"read", "write"}, true},
+		{"requested subset", []string{"read"}, nil, []string{"read", "write"}, []string{"read"}, true},
+		{"not allowed for client", []string{"admin"}, nil, []string{"read"}, nil, false},
+		{"limited by subject", []string{"write"}, []string{"read"}, []string{"read", "write"}, nil, false},
+		{"default limited by subject", nil, []string{"read"}, []string{"read", "write"}, []string{"read"}, true},
+		{"nothing allowed", nil, nil, nil, nil, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			scope, ok := exchangeScope(tc.requested, tc.subject, tc.allowed)
+			if ok != tc.ok || !slices.Equal(scope, tc.expected) {
+				t.Errorf("expected %v %t, got %v %t", tc.expected, tc.ok, scope, ok)
+			}
+		})
+	}
+}
+
+func TestExchangeToken(t *testing.T) {
+	ctx := context.Background()
+	user := &User{ID: bson.NewObjectID().Hex()}
+	repository := &testUserRepository{users: map[string]*User{user.ID: user}}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetAccessTokenTTL(time.Hour)
+	useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+	client := ServiceClient{ID: "orders", Audiences: []string{"billing"}, Scopes: []string{"billing:read", "billing:write"}}
+
+	subjectToken, err := jwtCfg.GenerateToken(ctx, *user, TokenOptions{TTL: 10 * time.Minute})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	if _, err := useCase.ExchangeToken(ctx, client, TokenExchangeRequest{SubjectToken: subjectToken, Audience: "payments"}); err == nil || err.Error() != invalidTarget.Error() {
+		t.Errorf("expected invalid target, got %v", err)
+	}
+	if _, err := useCase.ExchangeToken(ctx, client, TokenExchangeRequest{SubjectToken: "broken", Audience: "billing"}); err == nil || err.Error() != invalidGrant.Error() {
+		t.Errorf("expected invalid grant, got %v", err)
+	}
+
+	response, err := useCase.ExchangeToken(ctx, client, TokenExchangeRequest{SubjectToken: subjectToken, Audience: "billing", Scope: []string{"billing:read"}})
+	if err != nil {
+		t.Fatalf("ExchangeToken failed: %v", err)
+	}
+	if response.Scope != "billing:read" || response.IssuedTokenType != AccessTokenType {
+		t.Errorf("unexpected response: %+v", response)
+	}
+	if response.ExpiresIn > int64((10 * time.Minute).Seconds()) {
+		t.Errorf("exchanged token must not outlive the subject token, expires in %d", response.ExpiresIn)
+	}
+
+	billingCfg := jwtCfg.Copy()
+	billingCfg.SetAudience("billing")
+	info, err := billingCfg.ParseToken(response.AccessToken)
+	if err != nil {
+		t.Fatalf("billing should accept exchanged token: %v", err)
+	}
+	if info.User.ID != user.ID || info.ClientID != "orders" || !slices.Equal(info.Scope, []string{"billing:read"}) {
+		t.Errorf("unexpected exchanged token: %+v", info)
+	}
+
+	ordersCfg := jwtCfg.Copy()
+	ordersCfg.SetAudience("orders")
+	if _, err := ordersCfg.ParseToken(response.AccessToken); err == nil {
+		t.Error("expected exchanged token to be rejected by another audience")
+	}
+}
+
+func TestExchangeTokenKeepsActorAndBinding(t *testing.T) {
+	ctx := context.Background()
+	user := &User{ID: bson.NewObjectID().Hex()}
+	repository := &testUserRepository{users: map[string]*User{user.ID: user}}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+	client := ServiceClient{ID: "orders", Audiences: []string{"billing"}, Scopes: []string{"billing:read"}}
+
+	subjectToken, err := jwtCfg.GenerateToken(ctx, *user, TokenOptions{Actor: &Actor{ID: "admin", ReadOnly: true}, KeyThumbprint: "client-key"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	response, err := useCase.ExchangeToken(ctx, client, TokenExchangeRequest{SubjectToken: subjectToken, Audience: "billing"})
+	if err != nil {
+		t.Fatalf("ExchangeToken failed: %v", err)
+	}
+	billingCfg := jwtCfg.Copy()
+	billingCfg.SetAudience("billing")
+	info, err := billingCfg.ParseToken(response.AccessToken)
+	if err != nil {
+		t.Fatalf("ParseToken failed: %v", err)
+	}
+	if info.Actor == nil || info.Actor.ID != "admin" || !info.Actor.ReadOnly {
+		t.Errorf("expected read only actor to be kept, got %+v", info.Actor)
+	}
+	if info.KeyThumbprint != "client-key" {
+		t.Errorf("expected DPoP binding to be kept, got %q", info.KeyThumbprint)
+	}
+}
+
+func TestScopedTokens(t *testing.T) {
+	ctx := context.Background()
+	user := User{ID: bson.NewObjectID().Hex()}
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	userToken, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	scopedToken, err := jwtCfg.GenerateToken(ctx, user, TokenOptions{Scope: []string{"billing:read"}, ClientID: "orders"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		handler http.Handler
+		token   string
+		status  int
+	}{
+		{"user route with user token", UserMiddlewareFactory(*jwtCfg)(ok), userToken, http.StatusOK},
+		{"user route with scoped token", UserMiddlewareFactory(*jwtCfg)(ok), scopedToken, http.StatusForbidden},
+		{"scoped route with scoped token", ScopedUserMiddlewareFactory(*jwtCfg, "billing:read")(ok), scopedToken, http.StatusOK},
+		{"scoped route with missing scope", ScopedUserMiddlewareFactory(*jwtCfg, "billing:write")(ok), scopedToken, http.StatusForbidden},
+		{"scoped route with user token", ScopedUserMiddlewareFactory(*jwtCfg, "billing:write")(ok), userToken, http.StatusOK},
+		{"required scope", ScopedUserMiddlewareFactory(*jwtCfg)(RequireScope("billing:read")(ok)), scopedToken, http.StatusOK},
+		{"required missing scope", ScopedUserMiddlewareFactory(*jwtCfg)(RequireScope("billing:write")(ok)), scopedToken, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/force-delete", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rec := httptest.NewRecorder()
+			tc.handler.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Errorf("expected %d, got %d", tc.status, rec.Code)
+			}
+		})
+	}
+
+	var seen *User
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("Authorization", "Bearer "+scopedToken)
+	MaybeUserMiddlewareFactory(*jwtCfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetUserFromRequest(r)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	if seen != nil {
+		t.Error("expected scoped token not to attach the user")
+	}
+}
@@ -0,0 +1,58 @@
+package goauthlib
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// TokenFormat signs claims into a token and verifies them back. JWTConfig uses golang-jwt when format is not set.
+// Formats verify only the signature, time and audience claims are checked by JWTConfig.
+type TokenFormat interface {
+	Sign(ctx context.Context, claims any) (string, error)
+	Parse(token string) (map[string]any, error)
+}
+
+// NewJWTConfigWithFormat creates config which issues and parses tokens in the given format.
+func NewJWTConfigWithFormat(format TokenFormat, blinder string) *JWTConfig {
+	return &JWTConfig{format: format, blinder: blinder}
+}
+
+// validateClaims checks registered claims of tokens parsed by TokenFormat.
+func (config JWTConfig) validateClaims(claims map[string]any) error {
+	now := float64(time.Now().Unix())
+	if exp, ok := claims["exp"].(float64); ok && now >= exp {
+		return errors.New("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now < nbf {
+		return errors.New("token is not valid yet")
+	}
+	if claims["iss"] != config.Issuer() {
+		return errors.New("token has invalid issuer")
+	}
+	if len(config.audience) > 0 && !containsAudience(claims["aud"], config.audience) {
+		return errors.New("token has invalid audience")
+	}
+	return nil
+}
+
+func containsAudience(aud any, expected []string) bool {
+	var audience []string
+	switch value := aud.(type) {
+	case string:
+		audience = []string{value}
+	case []any:
+		for _, item := range value {
+			if str, ok := item.(string); ok {
+				audience = append(audience, str)
+			}
+		}
+	}
+	for _, item := range audience {
+		if slices.Contains(expected, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,18 +1,57 @@
 package goauthlib
 
 import (
+	"context"
 	"github.com/techpro-studio/gohttplib"
 	"net/http"
 )
 
 type Transport struct {
 	useCase UseCase
+	cookie  *CookieConfig
+	proxies *TrustedProxies
 }
 
 func NewTransport(useCase UseCase) *Transport {
 	return &Transport{useCase: useCase}
 }
 
+// SetCookieConfig enables cookie mode: login handlers set tokens in cookies instead of the response body.
+func (t *Transport) SetCookieConfig(cookie *CookieConfig) {
+	t.cookie = cookie
+}
+
+// SetTrustedProxies enables forwarded headers of requests coming from the proxies, see TrustedProxies.
+// Without it client IP is the remote address of the connection.
+func (t *Transport) SetTrustedProxies(proxies *TrustedProxies) {
+	t.proxies = proxies
+}
+
+// loginResponse moves tokens of the response to cookies in cookie mode.
+func (t *Transport) loginResponse(w http.ResponseWriter, response *Response, err error) (interface{}, error) {
+	if err != nil || t.cookie == nil {
+		return response, err
+	}
+	err = t.cookie.SetTokens(w, response)
+	if err != nil {
+		return nil, err
+	}
+	cookieResponse := *response
+	cookieResponse.Token = ""
+	cookieResponse.RefreshToken = ""
+	return cookieResponse, nil
+}
+
+// loginContext carries client info for sessions created by login handlers and for send quotas.
+// The token the client already has is passed for step-up, see WithStepUpToken.
+func (t *Transport) loginContext(r *http.Request) context.Context {
+	token := ExtractToken(r, DefaultTokenExtractors()...)
+	if token == "" && t.cookie != nil {
+		token = t.cookie.Token(r)
+	}
+	return WithStepUpToken(WithClientInfo(r.Context(), t.proxies.ClientInfoFromRequest(r)), token)
+}
+
 func (t *Transport) withBody(w http.ResponseWriter, r *http.Request, handler func(body map[string]interface{}) (interface{}, error)) {
 	body, err := gohttplib.GetBody(r)
 	if err != nil {
@@ -23,6 +62,25 @@ func (t *Transport) withBody(w http.ResponseWriter, r *http.Request, handler fun
 	gohttplib.WriteJsonOrError(w, resp, 200, err)
 }
 
+// withFormOrBody accepts application/x-www-form-urlencoded body required by OAuth RFCs as well as JSON.
+func (t *Transport) withFormOrBody(w http.ResponseWriter, r *http.Request, handler func(body map[string]interface{}) (interface{}, error)) {
+	if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.withBody(w, r, handler)
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		gohttplib.HTTP400(err.Error()).Write(w)
+		return
+	}
+	body := map[string]interface{}{}
+	for key := range r.PostForm {
+		body[key] = r.PostForm.Get(key)
+	}
+	resp, err := handler(body)
+	gohttplib.WriteJsonOrError(w, resp, 200, err)
+}
+
 func (t *Transport) withAuthorizationEntity(w http.ResponseWriter, r *http.Request, handler func(entity AuthorizationEntity) (interface{}, error)) {
 	t.withBody(w, r, func(body map[string]interface{}) (i interface{}, e error) {
 		entity, err := GetAuthorizationEntityFromBody(body)
@@ -46,7 +104,10 @@ func (t *Transport) withOAuthPayload(w http.ResponseWriter, r *http.Request, han
 func (t *Transport) withAuthorizationEntityAndCode(w http.ResponseWriter, r *http.Request, handler func(entity AuthorizationEntity, code string) (interface{}, error)) {
 	t.withBody(w, r, func(body map[string]interface{}) (i interface{}, e error) {
 		entity, err := GetAuthorizationEntityFromBody(body)
-		code, err := GetCode(body)
+		if err != nil {
+			return nil, err
+		}
+		code, err := GetCodeForSpec(body, t.useCase.CodeSpec(entity.Type))
 		if err != nil {
 			return nil, err
 		}
@@ -60,19 +121,20 @@ func (t *Transport) CurrentUserHandler(w http.ResponseWriter, r *http.Request) {
 
 func (t *Transport) AuthenticateViaSocialProviderHandler(w http.ResponseWriter, r *http.Request) {
 	t.withOAuthPayload(w, r, func(payload SocialProviderPayload) (i interface{}, e error) {
-		return t.useCase.AuthenticateViaSocialProvider(r.Context(), payload)
+		response, err := t.useCase.AuthenticateViaSocialProvider(t.loginContext(r), payload)
+		return t.loginResponse(w, response, err)
 	})
 }
 
 func (t *Transport) SendCodeHandler(w http.ResponseWriter, r *http.Request) {
 	t.withAuthorizationEntity(w, r, func(entity AuthorizationEntity) (i interface{}, e error) {
-		return OK, t.useCase.SendCode(r.Context(), entity)
+		return OK, t.useCase.SendCode(t.loginContext(r), entity)
 	})
 }
 
 func (t *Transport) SendVerificationCodeHandler(writer http.ResponseWriter, request *http.Request) {
 	usr := GetUserFromRequestWithPanic(request)
-	err := t.useCase.SendVerificationCode(request.Context(), usr, deleteAccountAction)
+	err := t.useCase.SendVerificationCode(request.Context(), usr, DeleteAccountAction)
 	gohttplib.WriteJsonOrError(writer, OK, 200, err)
 }
 
@@ -83,7 +145,7 @@ func (t *Transport) VerifyDeleteHandler(writer http.ResponseWriter, request *htt
 		gohttplib.SafeConvertToServerError(err).Write(writer)
 		return
 	}
-	code, err := GetCode(body)
+	code, err := GetCodeForSpec(body, t.useCase.CodeSpec(EntityTypeEmail))
 	if err != nil {
 		gohttplib.SafeConvertToServerError(err).Write(writer)
 		return
@@ -100,10 +162,71 @@ func (t *Transport) ForceDeleteHandler(writer http.ResponseWriter, request *http
 
 func (t *Transport) AuthenticateWithCodeHandler(w http.ResponseWriter, r *http.Request) {
 	t.withAuthorizationEntityAndCode(w, r, func(entity AuthorizationEntity, code string) (i interface{}, e error) {
-		return t.useCase.AuthenticateWithCode(r.Context(), entity, code)
+		response, err := t.useCase.AuthenticateWithCode(t.loginContext(r), entity, code)
+		return t.loginResponse(w, response, err)
 	})
 }
 
+func (t *Transport) SendMagicLinkHandler(w http.ResponseWriter, r *http.Request) {
+	t.withAuthorizationEntity(w, r, func(entity AuthorizationEntity) (i interface{}, e error) {
+		challenge, err := t.useCase.SendMagicLink(t.loginContext(r), entity)
+		if err != nil || challenge == nil {
+			return OK, err
+		}
+		return map[string]interface{}{"ok": 1, "device_token": challenge.DeviceToken, "confirmation_code": challenge.ConfirmationCode}, nil
+	})
+}
+
+func (t *Transport) MagicLinkHandler(w http.ResponseWriter, r *http.Request) {
+	t.withBody(w, r, func(body map[string]interface{}) (i interface{}, e error) {
+		request, err := GetMagicLinkRequest(body)
+		if err != nil {
+			return nil, err
+		}
+		response, err := t.useCase.AuthenticateWithMagicLink(t.loginContext(r), *request)
+		return t.loginResponse(w, response, err)
+	})
+}
+
+func (t *Transport) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if t.cookie != nil {
+		if refreshToken := t.cookie.RefreshToken(r); refreshToken != "" {
+			response, err := t.useCase.Refresh(t.loginContext(r), refreshToken)
+			resp, err := t.loginResponse(w, response, err)
+			gohttplib.WriteJsonOrError(w, resp, 200, err)
+			return
+		}
+	}
+	t.withBody(w, r, func(body map[string]interface{}) (i interface{}, e error) {
+		refreshToken, err := GetRefreshToken(body)
+		if err != nil {
+			return nil, err
+		}
+		response, err := t.useCase.Refresh(t.loginContext(r), refreshToken)
+		return t.loginResponse(w, response, err)
+	})
+}
+
+// LogoutHandler always clears cookies. Body is optional, it may contain refresh_token of header based clients.
+func (t *Transport) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	token := ExtractToken(r, DefaultTokenExtractors()...)
+	refreshToken := ""
+	if body, err := gohttplib.GetBody(r); err == nil {
+		refreshToken, _ = body["refresh_token"].(string)
+	}
+	if t.cookie != nil {
+		if token == "" {
+			token = t.cookie.Token(r)
+		}
+		if refreshToken == "" {
+			refreshToken = t.cookie.RefreshToken(r)
+		}
+		t.cookie.Clear(w)
+	}
+	err := t.useCase.Logout(r.Context(), token, refreshToken)
+	gohttplib.WriteJsonOrError(w, OK, 200, err)
+}
+
 func (t *Transport) RemoveAuthenticationEntityHandler(w http.ResponseWriter, r *http.Request) {
 	t.withAuthorizationEntity(w, r, func(entity AuthorizationEntity) (i interface{}, e error) {
 		return OK, t.useCase.RemoveAuthenticationEntity(r.Context(), GetUserFromRequestWithPanic(r), entity)
@@ -119,7 +242,7 @@ func (t *Transport) AddSocialAuthenticationEntityHandler(w http.ResponseWriter,
 
 func (t *Transport) SendCodeWithUserHandler(w http.ResponseWriter, r *http.Request) {
 	t.withAuthorizationEntity(w, r, func(entity AuthorizationEntity) (i interface{}, e error) {
-		return OK, t.useCase.SendCodeWithUser(r.Context(), GetUserFromRequestWithPanic(r), entity)
+		return OK, t.useCase.SendCodeWithUser(t.loginContext(r), GetUserFromRequestWithPanic(r), entity)
 	})
 }
 
@@ -140,3 +263,87 @@ func (t *Transport) PatchInfoHandler(w http.ResponseWriter, r *http.Request) {
 	patched, err := t.useCase.PatchUserInfo(r.Context(), &usr, body)
 	gohttplib.WriteJsonOrError(w, patched, 200, err)
 }
+
+func (t *Transport) ListSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	sessions, err := t.useCase.ListSessions(r.Context(), GetUserFromRequestWithPanic(r))
+	gohttplib.WriteJsonOrError(w, sessions, 200, err)
+}
+
+func (t *Transport) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	t.withBody(w, r, func(body map[string]interface{}) (i interface{}, e error) {
+		sessionId, err := GetSessionId(body)
+		if err != nil {
+			return nil, err
+		}
+		return OK, t.useCase.RevokeSession(r.Context(), GetUserFromRequestWithPanic(r), sessionId)
+	})
+}
+
+func (t *Transport) RevokeAllSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	err := t.useCase.RevokeAllSessions(r.Context(), GetUserFromRequestWithPanic(r))
+	gohttplib.WriteJsonOrError(w, OK, 200, err)
+}
+
+func (t *Transport) InvalidateTokensHandler(w http.ResponseWriter, r *http.Request) {
+	err := t.useCase.InvalidateTokens(r.Context(), GetUserFromRequestWithPanic(r))
+	gohttplib.WriteJsonOrError(w, OK, 200, err)
+}
+
+func (t *Transport) IntrospectHandler(w http.ResponseWriter, r *http.Request) {
+	t.withFormOrBody(w, r, func(body map[string]interface{}) (i interface{}, e error) {
+		token, err := GetTokenFromBody(body)
+		if err != nil {
+			return nil, err
+		}
+		return t.useCase.Introspect(r.Context(), token)
+	})
+}
+
+func (t *Transport) StartImpersonationHandler(w http.ResponseWriter, r *http.Request) {
+	t.withBody(w, r, func(body map[string]interface{}) (i interface{}, e error) {
+		request, err := GetImpersonationRequest(body)
+		if err != nil {
+			return nil, err
+		}
+		return t.useCase.StartImpersonation(r.Context(), GetUserFromRequestWithPanic(r), *request)
+	})
+}
+
+func (t *Transport) StopImpersonationHandler(w http.ResponseWriter, r *http.Request) {
+	err := t.useCase.StopImpersonation(r.Context(), GetUserFromRequestWithPanic(r))
+	gohttplib.WriteJsonOrError(w, OK, 200, err)
+}
+
+func (t *Transport) TokenExchangeHandler(w http.ResponseWriter, r *http.Request) {
+	t.withFormOrBody(w, r, func(body map[string]interface{}) (i interface{}, e error) {
+		request, err := GetTokenExchangeRequest(body)
+		if err != nil {
+			return nil, err
+		}
+		client := GetServiceClientFromRequest(r)
+		if client == nil {
+			return nil, gohttplib.HTTP401()
+		}
+		return t.useCase.ExchangeToken(r.Context(), *client, *request)
+	})
+}
+
+func (t *Transport) GrantRoleHandler(w http.ResponseWriter, r *http.Request) {
+	t.withBody(w, r, func(body map[string]interface{}) (i interface{}, e error) {
+		userId, role, err := GetRoleRequest(body)
+		if err != nil {
+			return nil, err
+		}
+		return t.useCase.GrantRole(r.Context(), userId, role)
+	})
+}
+
+func (t *Transport) RevokeRoleHandler(w http.ResponseWriter, r *http.Request) {
+	t.withBody(w, r, func(body map[string]interface{}) (i interface{}, e error) {
+		userId, role, err := GetRoleRequest(body)
+		if err != nil {
+			return nil, err
+		}
+		return t.useCase.RevokeRole(r.Context(), userId, role)
+	})
+}
@@ -11,10 +11,25 @@ type UseCase interface {
 	RegisterOTPDelivery(key string, delivery OTPDelivery)
 	AuthenticateViaSocialProvider(ctx context.Context, payload SocialProviderPayload) (*Response, error)
 	SendCode(ctx context.Context, entity AuthorizationEntity) error
+	CodeSpec(entityType string) CodeSpec
 	SendVerificationCode(ctx context.Context, user User, action string) error
 	UpsertUser(ctx context.Context, entity AuthorizationEntity, info map[string]any) (*Response, error)
 	VerifyDelete(ctx context.Context, user User, code string) error
 	AuthenticateWithCode(ctx context.Context, entity AuthorizationEntity, code string) (*Response, error)
+	SendMagicLink(ctx context.Context, entity AuthorizationEntity) (*MagicLinkChallenge, error)
+	AuthenticateWithMagicLink(ctx context.Context, request MagicLinkRequest) (*Response, error)
+	Refresh(ctx context.Context, refreshToken string) (*Response, error)
+	Logout(ctx context.Context, token string, refreshToken string) error
+	ListSessions(ctx context.Context, user User) ([]*Session, error)
+	RevokeSession(ctx context.Context, user User, sessionId string) error
+	RevokeAllSessions(ctx context.Context, user User) error
+	InvalidateTokens(ctx context.Context, user User) error
+	Introspect(ctx context.Context, token string) (*IntrospectionResponse, error)
+	ExchangeToken(ctx context.Context, client ServiceClient, request TokenExchangeRequest) (*TokenExchangeResponse, error)
+	StartImpersonation(ctx context.Context, admin User, request ImpersonationRequest) (*Response, error)
+	StopImpersonation(ctx context.Context, user User) error
+	GrantRole(ctx context.Context, userId string, role string) (*User, error)
+	RevokeRole(ctx context.Context, userId string, role string) (*User, error)
 	RemoveAuthenticationEntity(ctx context.Context, user User, entity AuthorizationEntity) error
 	SendCodeWithUser(ctx context.Context, user User, entity AuthorizationEntity) error
 	AddSocialAuthenticationEntity(ctx context.Context, user *User, payload SocialProviderPayload) (*User, error)
@@ -0,0 +1,104 @@
+package goauthlib
+
+import (
+	"context"
+	"github.com/techpro-studio/gohttplib"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// UserCache keeps users loaded by UserLoaderMiddlewareFactory.
+type UserCache interface {
+	Get(id string) *User
+	Set(user *User)
+	Invalidate(id string)
+}
+
+type cachedUser struct {
+	user      *User
+	expiresAt time.Time
+}
+
+// MemoryUserCache keeps users for ttl. Changes of the user become visible after ttl passes or Invalidate is called.
+type MemoryUserCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	users map[string]cachedUser
+}
+
+func NewMemoryUserCache(ttl time.Duration) *MemoryUserCache {
+	return &MemoryUserCache{ttl: ttl, users: map[string]cachedUser{}}
+}
+
+func (c *MemoryUserCache) Get(id string) *User {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.users[id]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(cached.expiresAt) {
+		delete(c.users, id)
+		return nil
+	}
+	return cached.user
+}
+
+func (c *MemoryUserCache) Set(user *User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.users[user.ID] = cachedUser{user: user, expiresAt: time.Now().Add(c.ttl)}
+}
+
+func (c *MemoryUserCache) Invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.users, id)
+}
+
+// UserLoaderMiddlewareFactory validates the token like UserMiddlewareFactory and replaces the user from claims
+// with the current one from repository. It is meant for slim claims. Cache is optional and may be nil.
+func UserLoaderMiddlewareFactory(config JWTConfig, repository Repository, cache UserCache) gohttplib.Middleware {
+	validate := UserMiddlewareFactory(config)
+	return func(next http.Handler) http.Handler {
+		return validate(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			current := GetUserFromRequestWithPanic(req)
+			user := LoadUser(req.Context(), repository, cache, current.ID)
+			if user == nil {
+				gohttplib.HTTP401().Write(w)
+				return
+			}
+			user.Actor = current.Actor
+			ctx := context.WithValue(req.Context(), CurrentUserContextKey, user)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		}))
+	}
+}
+
+// LoadUser returns the current user from cache or repository, e.g. for transports other than HTTP.
+// Cache is optional and may be nil.
+func LoadUser(ctx context.Context, repository Repository, cache UserCache, id string) *User {
+	if cache != nil {
+		if user := cache.Get(id); user != nil {
+			// Handlers may modify the user, so they never get the cached one.
+			return cloneUser(user)
+		}
+	}
+	user := repository.GetById(ctx, id)
+	if user != nil && cache != nil {
+		cache.Set(cloneUser(user))
+	}
+	return user
+}
+
+func cloneUser(user *User) *User {
+	cloned := *user
+	cloned.Entities = slices.Clone(user.Entities)
+	cloned.Info = maps.Clone(user.Info)
+	cloned.Roles = slices.Clone(user.Roles)
+	cloned.Permissions = slices.Clone(user.Permissions)
+	return &cloned
+}
@@ -0,0 +1,181 @@
+package goauthlib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/techpro-studio/goauthlib/oauth"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type testUserRepository struct {
+	Repository
+	users map[string]*User
+	loads int
+}
+
+func (r *testUserRepository) GetById(ctx context.Context, id string) *User {
+	r.loads++
+	return r.users[id]
+}
+
+func (r *testUserRepository) Save(ctx context.Context, model *User) {
+	r.users[model.ID] = model
+}
+
+func (r *testUserRepository) GetForEntity(ctx context.Context, entity AuthorizationEntity) *User {
+	for _, user := range r.users {
+		if len(user.Entities) > 0 && user.Entities[0] == entity {
+			return user
+		}
+	}
+	return nil
+}
+
+func (r *testUserRepository) CreateForEntity(ctx context.Context, entity AuthorizationEntity) *User {
+	user := &User{ID: bson.NewObjectID().Hex(), Entities: []AuthorizationEntity{entity}, Info: map[string]any{}}
+	r.users[user.ID] = user
+	return user
+}
+
+func (r *testUserRepository) EnsureService(ctx context.Context, id string) bool {
+	return false
+}
+
+func TestSlimClaims(t *testing.T) {
+	user := User{
+		ID:       bson.NewObjectID().Hex(),
+		Entities: []AuthorizationEntity{{Type: EntityTypeEmail, Value: "slim@test.com"}},
+		Info:     map[string]any{"name": "John", "phone": "+111"},
+	}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetSlimClaims("name")
+
+	token, err := jwtCfg.GenerateTokenFromModel(user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	claims, err := jwtCfg.GetClaimsFromToken(token)
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if _, ok := claims["user"]; ok {
+		t.Error("expected no user claim in slim token")
+	}
+	if claims["sub"] != user.ID {
+		t.Errorf("expected sub %s, got %v", user.ID, claims["sub"])
+	}
+
+	validUser, err := jwtCfg.GetValidUserFromToken(token)
+	if err != nil {
+		t.Fatalf("GetValidUserFromToken failed: %v", err)
+	}
+	if validUser.ID != user.ID || validUser.Info["name"] != "John" {
+		t.Errorf("unexpected user from slim token: %+v", validUser)
+	}
+	if _, ok := validUser.Info["phone"]; ok || len(validUser.Entities) != 0 {
+		t.Error("expected slim token not to leak entities and not chosen info")
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "JWT "+token)
+	userId, err := jwtCfg.SafeExtractUserIdFromHeader(header)
+	if err != nil || userId != user.ID {
+		t.Errorf("SafeExtractUserIdFromHeader failed: %v, %s", err, userId)
+	}
+}
+
+func TestUserLoaderMiddleware(t *testing.T) {
+	user := &User{
+		ID:       bson.NewObjectID().Hex(),
+		Entities: []AuthorizationEntity{{Type: EntityTypeEmail, Value: "loader@test.com"}},
+		Info:     map[string]any{"name": "John"},
+	}
+	repository := &testUserRepository{users: map[string]*User{user.ID: user}}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetSlimClaims()
+	token, err := jwtCfg.GenerateTokenFromModel(*user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	var loaded *User
+	var loadedName any
+	handler := UserLoaderMiddlewareFactory(*jwtCfg, repository, NewMemoryUserCache(time.Minute))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loaded = GetUserFromRequest(r)
+		loadedName = loaded.Info["name"]
+		loaded.Info["name"] = "Changed by handler"
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/user", nil)
+		req.Header.Set("Authorization", "JWT "+token)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if loaded == nil || len(loaded.Entities) != 1 {
+			t.Fatalf("expected whole user to be loaded, got %+v", loaded)
+		}
+		if loadedName != "John" {
+			t.Errorf("expected cached user not to be modified by handlers, got %v", loadedName)
+		}
+	}
+	if repository.loads != 1 {
+		t.Errorf("expected user to be loaded once, got %d", repository.loads)
+	}
+
+	delete(repository.users, user.ID)
+	req := httptest.NewRequest("GET", "/user", nil)
+	req.Header.Set("Authorization", "JWT "+token)
+	recorder := httptest.NewRecorder()
+	UserLoaderMiddlewareFactory(*jwtCfg, repository, nil)(http.NotFoundHandler()).ServeHTTP(recorder, req)
+	if recorder.Code != 401 {
+		t.Errorf("expected 401 for missing user, got %d", recorder.Code)
+	}
+}
+
+func TestUseCaseReloadsSlimUser(t *testing.T) {
+	ctx := context.Background()
+	entity := AuthorizationEntity{Type: EntityTypeEmail, Value: "slim@test.com"}
+	stored := &User{ID: bson.NewObjectID().Hex(), Entities: []AuthorizationEntity{entity}, Info: map[string]any{"name": "John", "phone": "+111"}}
+	repository := &testVersionRepository{
+		testRefreshRepository: testRefreshRepository{testUserRepository: testUserRepository{users: map[string]*User{stored.ID: stored}}, refreshTokens: map[string]*RefreshToken{}},
+		versions:              map[string]int64{},
+	}
+
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	jwtCfg.SetSlimClaims()
+	useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+	useCase.RegisterSocialProvider("google", testSocialProvider{result: oauth.ProviderResult{ID: "google-id", Type: "google"}})
+
+	token, err := jwtCfg.GenerateTokenFromModel(*stored)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	slim, err := jwtCfg.GetValidUserFromToken(token)
+	if err != nil {
+		t.Fatalf("GetValidUserFromToken failed: %v", err)
+	}
+
+	patched, err := useCase.PatchUserInfo(ctx, slim, map[string]interface{}{"name": "Jane"})
+	if err != nil {
+		t.Fatalf("PatchUserInfo failed: %v", err)
+	}
+	saved := repository.users[stored.ID]
+	if patched.Info["name"] != "Jane" || saved.Info["name"] != "Jane" || saved.Info["phone"] != "+111" || len(saved.Entities) != 1 {
+		t.Errorf("expected patch on top of the stored user, got %+v", saved)
+	}
+
+	updated, err := useCase.AddSocialAuthenticationEntity(ctx, slim, SocialProviderPayload{Provider: "google", Payload: "token", PayloadType: "token"})
+	if err != nil {
+		t.Fatalf("AddSocialAuthenticationEntity failed: %v", err)
+	}
+	saved = repository.users[stored.ID]
+	if len(updated.Entities) != 2 || len(saved.Entities) != 2 || saved.Entities[0] != entity || saved.Info["phone"] != "+111" {
+		t.Errorf("expected social entity next to stored entities, got %+v", saved)
+	}
+}
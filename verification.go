@@ -0,0 +1,56 @@
+package goauthlib
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"github.com/techpro-studio/gohttplib"
+	"time"
+)
+
+const (
+	defaultCodeTTL         = 10 * time.Minute
+	defaultMaxCodeAttempts = 5
+)
+
+// checkVerification compares the code with the verification. The attempt is counted before the comparison,
+// so parallel guesses can't exceed the limit. Expired verification is deleted, exhausted one is kept
+// until a new code is sent, so the lockout can't be bypassed.
+func (useCase *DefaultUseCase) checkVerification(ctx context.Context, verification *Verification, code string) error {
+	if useCase.codeTTL > 0 && time.Since(time.Unix(verification.Timestamp, 0)) > useCase.codeTTL {
+		useCase.repository.DeleteVerification(ctx, verification.ID)
+		return codeExpired
+	}
+	if useCase.maxCodeAttempts > 0 {
+		attempts := useCase.repository.IncrementVerificationAttempts(ctx, verification.ID)
+		if attempts == 0 {
+			return gohttplib.HTTP404(verification.Destination)
+		}
+		if attempts > useCase.maxCodeAttempts {
+			return tooManyAttempts
+		}
+	}
+	if !useCase.codeMatches(verification, code) {
+		return invalidCode
+	}
+	return nil
+}
+
+func (useCase *DefaultUseCase) hashCode(code string) string {
+	mac := hmac.New(sha256.New, useCase.codeSecret)
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// codeMatches compares in constant time, so response time doesn't tell how much of the code is right.
+func (useCase *DefaultUseCase) codeMatches(verification *Verification, code string) bool {
+	if verification.CodeHash != "" {
+		return subtle.ConstantTimeCompare([]byte(useCase.hashCode(code)), []byte(verification.CodeHash)) == 1
+	}
+	if verification.Code == "" || time.Now().After(useCase.plaintextCodesUntil) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(code), []byte(verification.Code)) == 1
+}
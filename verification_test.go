@@ -0,0 +1,145 @@
+package goauthlib
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type testVerificationRepository struct {
+	testUserRepository
+	verification *Verification
+	deleted      bool
+}
+
+func (r *testVerificationRepository) CreateVerificationForEntity(ctx context.Context, entity AuthorizationEntity, codeHash string) {
+	r.verification = &Verification{ID: bson.NewObjectID().Hex(), CodeHash: codeHash, Destination: entity.Value, DestinationType: entity.Type, Timestamp: time.Now().Unix()}
+	r.deleted = false
+}
+
+func (r *testVerificationRepository) GetVerificationForEntity(ctx context.Context, entity AuthorizationEntity) *Verification {
+	if r.deleted {
+		return nil
+	}
+	return r.verification
+}
+
+func (r *testVerificationRepository) IncrementVerificationAttempts(ctx context.Context, id string) int {
+	if r.deleted {
+		return 0
+	}
+	r.verification.Attempts++
+	return r.verification.Attempts
+}
+
+func (r *testVerificationRepository) DeleteVerification(ctx context.Context, id string) {
+	r.deleted = true
+}
+
+func TestVerificationCodeLimits(t *testing.T) {
+	jwtCfg := NewJWTConfig(jwt.SigningMethodHS256, []byte("my-secret-key"), []byte("my-secret-key"), "test-blinder")
+	entity := AuthorizationEntity{Type: EntityTypeEmail, Value: "limits@test.com"}
+	ctx := context.Background()
+
+	t.Run("expired", func(t *testing.T) {
+		repository := &testVerificationRepository{verification: &Verification{ID: "1", Code: "123456", Timestamp: time.Now().Add(-time.Hour).Unix()}}
+		useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+		_, err := useCase.getVerificationAndCompare(ctx, entity, "123456")
+		if err == nil || err.Error() != codeExpired.Error() {
+			t.Fatalf("expected CODE_EXPIRED, got %v", err)
+		}
+		if !repository.deleted {
+			t.Error("expected expired verification to be deleted")
+		}
+	})
+
+	t.Run("too many attempts", func(t *testing.T) {
+		repository := &testVerificationRepository{verification: &Verification{ID: "1", Code: "123456", Timestamp: time.Now().Unix()}}
+		useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+		useCase.SetMaxCodeAttempts(3)
+		for i := 0; i < 3; i++ {
+			_, err := useCase.getVerificationAndCompare(ctx, entity, "000000")
+			if err == nil || err.Error() != invalidCode.Error() {
+				t.Fatalf("attempt %d: expected INVALID_CODE, got %v", i+1, err)
+			}
+		}
+		// Even the right code is rejected after the lockout
+		_, err := useCase.getVerificationAndCompare(ctx, entity, "123456")
+		if err == nil || err.Error() != tooManyAttempts.Error() {
+			t.Fatalf("expected TOO_MANY_ATTEMPTS, got %v", err)
+		}
+	})
+
+	t.Run("valid code", func(t *testing.T) {
+		repository := &testVerificationRepository{verification: &Verification{ID: "1", Code: "123456", Timestamp: time.Now().Unix()}}
+		useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+		verification, err := useCase.getVerificationAndCompare(ctx, entity, "123456")
+		if err != nil || verification.ID != "1" {
+			t.Fatalf("expected verification, got %v, %v", verification, err)
+		}
+	})
+
+	t.Run("hashed code", func(t *testing.T) {
+		useCase := NewDefaultUseCase(nil, *jwtCfg, DoNothingCallback())
+		useCase.SetCodeSecret([]byte("code-secret"))
+		repository := &testVerificationRepository{verification: &Verification{ID: "1", CodeHash: useCase.hashCode("123456"), Timestamp: time.Now().Unix()}}
+		useCase.repository = repository
+		if _, err := useCase.getVerificationAndCompare(ctx, entity, "654321"); err == nil || err.Error() != invalidCode.Error() {
+			t.Fatalf("expected INVALID_CODE, got %v", err)
+		}
+		if _, err := useCase.getVerificationAndCompare(ctx, entity, "123456"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Hash depends on the secret
+		useCase.SetCodeSecret([]byte("another-secret"))
+		if _, err := useCase.getVerificationAndCompare(ctx, entity, "123456"); err == nil || err.Error() != invalidCode.Error() {
+			t.Fatalf("expected INVALID_CODE with another secret, got %v", err)
+		}
+	})
+
+	t.Run("plaintext migration window", func(t *testing.T) {
+		repository := &testVerificationRepository{verification: &Verification{ID: "1", Code: "123456", Timestamp: time.Now().Unix()}}
+		useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+		useCase.SetPlaintextCodesUntil(time.Now().Add(time.Minute))
+		if _, err := useCase.getVerificationAndCompare(ctx, entity, "123456"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		useCase.SetPlaintextCodesUntil(time.Now().Add(-time.Minute))
+		if _, err := useCase.getVerificationAndCompare(ctx, entity, "123456"); err == nil || err.Error() != invalidCode.Error() {
+			t.Fatalf("expected INVALID_CODE after the window, got %v", err)
+		}
+	})
+
+	t.Run("plaintext default cutoff", func(t *testing.T) {
+		repository := &testVerificationRepository{verification: &Verification{ID: "1", Code: "123456", Timestamp: time.Now().Unix()}}
+		useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+		if until := time.Until(useCase.plaintextCodesUntil); until <= 0 || until > defaultCodeTTL {
+			t.Fatalf("expected the window to end within code TTL, got %v", until)
+		}
+		if _, err := useCase.getVerificationAndCompare(ctx, entity, "123456"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// The same use case after the default window
+		useCase.plaintextCodesUntil = time.Now().Add(-time.Second)
+		if _, err := useCase.getVerificationAndCompare(ctx, entity, "123456"); err == nil || err.Error() != invalidCode.Error() {
+			t.Fatalf("expected INVALID_CODE after the default window, got %v", err)
+		}
+		useCase.SetPlaintextCodesUntil(time.Time{})
+		if _, err := useCase.getVerificationAndCompare(ctx, entity, "123456"); err == nil || err.Error() != invalidCode.Error() {
+			t.Fatalf("expected INVALID_CODE without the window, got %v", err)
+		}
+	})
+
+	t.Run("limits disabled", func(t *testing.T) {
+		repository := &testVerificationRepository{verification: &Verification{ID: "1", Code: "123456", Timestamp: time.Now().Add(-time.Hour).Unix(), Attempts: 100}}
+		useCase := NewDefaultUseCase(repository, *jwtCfg, DoNothingCallback())
+		useCase.SetCodeTTL(0)
+		useCase.SetMaxCodeAttempts(0)
+		if _, err := useCase.getVerificationAndCompare(ctx, entity, "123456"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}